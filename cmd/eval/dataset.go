@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+)
+
+// Dataset is a set of scripted golden conversations
+type Dataset struct {
+	Conversations []Conversation `json:"conversations"`
+}
+
+// Conversation is a multi-turn script run against a fresh conversation ID
+type Conversation struct {
+	ID          string `json:"id"`
+	Description string `json:"description,omitempty"`
+	Turns       []Turn `json:"turns"`
+}
+
+// Turn is one customer message and what the orchestrator should do with it
+type Turn struct {
+	Message string `json:"message"`
+	Expect  Expect `json:"expect"`
+	// Fake scripts the plans returned by the fake LLM for this turn, in call order (-llm fake only)
+	Fake []core.ActionPlan `json:"fake,omitempty"`
+}
+
+// Expect lists the checks of a turn; empty fields are not scored
+type Expect struct {
+	Agent  string `json:"agent,omitempty"`
+	Action string `json:"action,omitempty"`
+	// Escalate defaults to Action == "escalate" when Action is set
+	Escalate *bool `json:"escalate,omitempty"`
+	// ReplyContains are key facts the reply must mention (case-insensitive)
+	ReplyContains []string `json:"reply_contains,omitempty"`
+}
+
+// escalation reports whether the turn should escalate and whether that is known at all
+func (e Expect) escalation() (want, known bool) {
+	if e.Escalate != nil {
+		return *e.Escalate, true
+	}
+	if e.Action != "" {
+		return e.Action == "escalate", true
+	}
+	return false, false
+}
+
+// loadDataset reads and validates a dataset file
+func loadDataset(path string) (*Dataset, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var ds Dataset
+	if err := json.Unmarshal(b, &ds); err != nil {
+		return nil, fmt.Errorf("decode dataset: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for i, c := range ds.Conversations {
+		if c.ID == "" {
+			return nil, fmt.Errorf("conversation #%d: id is required", i+1)
+		}
+		if seen[c.ID] {
+			return nil, fmt.Errorf("conversation %q: duplicate id", c.ID)
+		}
+		seen[c.ID] = true
+		if len(c.Turns) == 0 {
+			return nil, fmt.Errorf("conversation %q: no turns", c.ID)
+		}
+		for j, t := range c.Turns {
+			if t.Message == "" {
+				return nil, fmt.Errorf("conversation %q, turn %d: message is required", c.ID, j+1)
+			}
+		}
+	}
+	return &ds, nil
+}
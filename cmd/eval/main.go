@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/api"
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+	"github.com/bonettibruno/Jota_ProdOps/internal/harness"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm/fake"
+	"github.com/joho/godotenv"
+)
+
+// main runs golden conversations through the real orchestrator and scores the outcome
+func main() {
+	datasetPath := flag.String("dataset", "eval/golden.json", "golden conversations (JSON)")
+	mode := flag.String("llm", "fake", "LLM client: live, replay or fake (plans scripted in the dataset)")
+	cassettePath := flag.String("cassette", "data/llm_cassette.jsonl", "cassette served in replay mode")
+	agentsDir := flag.String("agents", "config/agents", "declarative agents directory")
+	reportPath := flag.String("report", "", "write the full JSON report to this file")
+	timeout := flag.Duration("timeout", time.Minute, "timeout per turn")
+	verbose := flag.Bool("v", false, "keep the orchestrator logs")
+
+	var th Thresholds
+	flag.Float64Var(&th.Routing, "min-routing", 0.9, "minimum routing accuracy")
+	flag.Float64Var(&th.Action, "min-action", 0.8, "minimum action accuracy")
+	flag.Float64Var(&th.Handoff, "min-handoff", 0.8, "minimum handoff correctness")
+	flag.Float64Var(&th.EscalationPrecision, "min-escalation-precision", 0.8, "minimum escalation precision")
+	flag.Float64Var(&th.EscalationRecall, "min-escalation-recall", 0.9, "minimum escalation recall")
+	flag.Float64Var(&th.Facts, "min-facts", 0.7, "minimum key fact coverage in replies")
+	flag.Parse()
+
+	_ = godotenv.Load()
+
+	ds, err := loadDataset(*datasetPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var client llm.Client
+	if *mode != "fake" {
+		if client, err = harness.Client(*mode, *cassettePath); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	orch, err := harness.New(client, harness.Config{AgentsDir: *agentsDir, PreRouter: true})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	rep := run(orch, ds, *mode == "fake", *timeout)
+
+	rep.print(os.Stdout)
+	if *reportPath != "" {
+		b, _ := json.MarshalIndent(rep, "", "  ")
+		if err := os.WriteFile(*reportPath, b, 0o644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	if failed := rep.check(th); len(failed) > 0 {
+		fmt.Println("\nFAIL")
+		for _, f := range failed {
+			fmt.Println("  " + f)
+		}
+		os.Exit(1)
+	}
+	fmt.Println("\nPASS")
+}
+
+// run plays every conversation in order; each one gets a fresh conversation ID
+func run(orch *harness.Orchestrator, ds *Dataset, scripted bool, timeout time.Duration) *Report {
+	rep := &Report{Agents: make(map[string]*AgentScore)}
+	runID := time.Now().Format("20060102150405")
+
+	for _, c := range ds.Conversations {
+		rep.Conversations++
+		convID := fmt.Sprintf("eval-%s-%s", runID, c.ID)
+		prev := core.DefaultAgent
+
+		for i, t := range c.Turns {
+			// The fake client only knows the plans scripted for this turn
+			if scripted {
+				f := fake.New()
+				for _, p := range t.Fake {
+					f.On(fake.Rule{Response: fake.Plan(p), Times: 1})
+				}
+				api.SetLLMClient(f)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			res, latency, err := orch.Send(ctx, convID, t.Message)
+			cancel()
+
+			tr := TurnResult{
+				Conversation: c.ID,
+				Turn:         i + 1,
+				Message:      t.Message,
+				Expect:       t.Expect,
+				Agent:        res.Agent,
+				Action:       res.Action,
+				Reply:        res.Reply,
+				LatencyMs:    latency.Milliseconds(),
+				prevAgent:    prev,
+			}
+			if err != nil {
+				tr.Error = err.Error()
+			}
+			rep.score(tr)
+
+			if res.Agent != "" {
+				prev = res.Agent
+			}
+		}
+	}
+	return rep
+}
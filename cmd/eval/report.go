@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// TurnResult is the outcome of one scripted turn
+type TurnResult struct {
+	Conversation string   `json:"conversation"`
+	Turn         int      `json:"turn"`
+	Message      string   `json:"message"`
+	Expect       Expect   `json:"expect"`
+	Agent        string   `json:"agent"`
+	Action       string   `json:"action"`
+	Reply        string   `json:"reply"`
+	LatencyMs    int64    `json:"latency_ms"`
+	Error        string   `json:"error,omitempty"`
+	MissingFacts []string `json:"missing_facts,omitempty"`
+	// Failures lists the checks that did not pass (e.g. "agent", "action")
+	Failures []string `json:"failures,omitempty"`
+
+	prevAgent string
+}
+
+// Score is a ratio with its sample size; N == 0 means the metric was not exercised
+type Score struct {
+	Hits int     `json:"hits"`
+	N    int     `json:"n"`
+	Rate float64 `json:"rate"`
+}
+
+func (s *Score) add(hit bool) {
+	s.N++
+	if hit {
+		s.Hits++
+	}
+	s.Rate = float64(s.Hits) / float64(s.N)
+}
+
+func (s Score) String() string {
+	if s.N == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%5.1f%% (%d/%d)", s.Rate*100, s.Hits, s.N)
+}
+
+// AgentScore is the per-agent breakdown, keyed by the expected agent
+type AgentScore struct {
+	Routing Score `json:"routing"`
+	Action  Score `json:"action"`
+	Facts   Score `json:"facts"`
+}
+
+// Report aggregates every metric of an evaluation run
+type Report struct {
+	Conversations int `json:"conversations"`
+	Turns         int `json:"turns"`
+	Errors        int `json:"errors"`
+
+	Routing             Score `json:"routing_accuracy"`
+	Action              Score `json:"action_accuracy"`
+	Handoff             Score `json:"handoff_correctness"`
+	EscalationPrecision Score `json:"escalation_precision"`
+	EscalationRecall    Score `json:"escalation_recall"`
+	Facts               Score `json:"fact_coverage"`
+
+	Agents  map[string]*AgentScore `json:"agents"`
+	Results []TurnResult           `json:"results"`
+}
+
+// score checks a turn against its expectations and folds it into the report
+func (r *Report) score(t TurnResult) {
+	r.Turns++
+	if t.Error != "" {
+		r.Errors++
+		t.Failures = append(t.Failures, "error")
+	}
+
+	e := t.Expect
+	var agent *AgentScore
+	if e.Agent != "" {
+		if r.Agents[e.Agent] == nil {
+			r.Agents[e.Agent] = &AgentScore{}
+		}
+		agent = r.Agents[e.Agent]
+
+		hit := t.Agent == e.Agent
+		r.Routing.add(hit)
+		agent.Routing.add(hit)
+		if !hit {
+			t.Failures = append(t.Failures, "agent")
+		}
+
+		// Handoff turns are those where a transfer was expected or happened
+		wantHandoff := e.Agent != t.prevAgent
+		gotHandoff := t.Agent != t.prevAgent
+		if wantHandoff || gotHandoff {
+			hit := wantHandoff && t.Agent == e.Agent
+			r.Handoff.add(hit)
+			if !hit {
+				t.Failures = append(t.Failures, "handoff")
+			}
+		}
+	}
+
+	if e.Action != "" {
+		hit := t.Action == e.Action
+		r.Action.add(hit)
+		if agent != nil {
+			agent.Action.add(hit)
+		}
+		if !hit {
+			t.Failures = append(t.Failures, "action")
+		}
+	}
+
+	if want, known := e.escalation(); known {
+		got := t.Action == "escalate"
+		if got {
+			r.EscalationPrecision.add(want)
+		}
+		if want {
+			r.EscalationRecall.add(got)
+		}
+		if got != want {
+			t.Failures = append(t.Failures, "escalation")
+		}
+	}
+
+	reply := strings.ToLower(t.Reply)
+	for _, fact := range e.ReplyContains {
+		hit := strings.Contains(reply, strings.ToLower(fact))
+		r.Facts.add(hit)
+		if agent != nil {
+			agent.Facts.add(hit)
+		}
+		if !hit {
+			t.MissingFacts = append(t.MissingFacts, fact)
+		}
+	}
+	if len(t.MissingFacts) > 0 {
+		t.Failures = append(t.Failures, "facts")
+	}
+
+	r.Results = append(r.Results, t)
+}
+
+// Thresholds are the minimum rates a run must reach (metrics without samples are skipped)
+type Thresholds struct {
+	Routing             float64
+	Action              float64
+	Handoff             float64
+	EscalationPrecision float64
+	EscalationRecall    float64
+	Facts               float64
+}
+
+// check returns one message per metric below its threshold
+func (r *Report) check(th Thresholds) []string {
+	var failed []string
+	for _, m := range []struct {
+		name  string
+		score Score
+		min   float64
+	}{
+		{"routing_accuracy", r.Routing, th.Routing},
+		{"action_accuracy", r.Action, th.Action},
+		{"handoff_correctness", r.Handoff, th.Handoff},
+		{"escalation_precision", r.EscalationPrecision, th.EscalationPrecision},
+		{"escalation_recall", r.EscalationRecall, th.EscalationRecall},
+		{"fact_coverage", r.Facts, th.Facts},
+	} {
+		if m.score.N > 0 && m.score.Rate < m.min {
+			failed = append(failed, fmt.Sprintf("%s %.1f%% < %.1f%%", m.name, m.score.Rate*100, m.min*100))
+		}
+	}
+	return failed
+}
+
+// print writes the human-readable summary and the failed turns
+func (r *Report) print(w io.Writer) {
+	fmt.Fprintf(w, "conversations=%d turns=%d errors=%d\n\n", r.Conversations, r.Turns, r.Errors)
+	fmt.Fprintf(w, "routing accuracy       %s\n", r.Routing)
+	fmt.Fprintf(w, "action accuracy        %s\n", r.Action)
+	fmt.Fprintf(w, "handoff correctness    %s\n", r.Handoff)
+	fmt.Fprintf(w, "escalation precision   %s\n", r.EscalationPrecision)
+	fmt.Fprintf(w, "escalation recall      %s\n", r.EscalationRecall)
+	fmt.Fprintf(w, "fact coverage          %s\n", r.Facts)
+
+	names := make([]string, 0, len(r.Agents))
+	for name := range r.Agents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(w, "\n%-20s %-20s %-20s %s\n", "agent", "routing", "action", "facts")
+	for _, name := range names {
+		a := r.Agents[name]
+		fmt.Fprintf(w, "%-20s %-20s %-20s %s\n", name, a.Routing, a.Action, a.Facts)
+	}
+
+	var failed []TurnResult
+	for _, t := range r.Results {
+		if len(t.Failures) > 0 {
+			failed = append(failed, t)
+		}
+	}
+	if len(failed) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nfailed turns:\n")
+	for _, t := range failed {
+		fmt.Fprintf(w, "- %s #%d [%s] %q\n", t.Conversation, t.Turn, strings.Join(t.Failures, ","), t.Message)
+		fmt.Fprintf(w, "    expected agent=%s action=%s, got agent=%s action=%s\n", t.Expect.Agent, t.Expect.Action, t.Agent, t.Action)
+		if len(t.MissingFacts) > 0 {
+			fmt.Fprintf(w, "    missing facts: %s\n", strings.Join(t.MissingFacts, ", "))
+		}
+		if t.Error != "" {
+			fmt.Fprintf(w, "    error: %s\n", t.Error)
+		}
+	}
+}
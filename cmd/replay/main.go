@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/api"
+	"github.com/bonettibruno/Jota_ProdOps/internal/harness"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm"
+	"github.com/joho/godotenv"
+)
+
+// Record is one logged customer message
+type Record struct {
+	ConversationID string    `json:"conversation_id"`
+	Message        string    `json:"message"`
+	Timestamp      time.Time `json:"timestamp"`
+
+	seq int
+}
+
+// Result is one replayed message, written as a line of the output JSONL
+type Result struct {
+	Seq            int       `json:"seq"`
+	ConversationID string    `json:"conversation_id"`
+	Message        string    `json:"message"`
+	Timestamp      time.Time `json:"timestamp"`
+	Agent          string    `json:"agent"`
+	Action         string    `json:"action"`
+	Reply          string    `json:"reply"`
+	Tool           string    `json:"tool,omitempty"`
+	LatencyMs      int64     `json:"latency_ms"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// sender delivers a message to an orchestrator (in-process or remote)
+type sender func(ctx context.Context, convID, message string) (api.MessageResponse, time.Duration, error)
+
+// main replays a JSONL traffic log and writes the responses for diffing between builds
+func main() {
+	inPath := flag.String("in", "eval/traffic.example.jsonl", "JSONL log with conversation_id, message and timestamp")
+	outPath := flag.String("out", "data/replay_out.jsonl", "output JSONL with agent, action, reply and latency")
+	target := flag.String("target", "", "base URL of a running server (empty runs the orchestrator in-process)")
+	mode := flag.String("llm", "live", "in-process LLM client: live, replay or none")
+	cassettePath := flag.String("cassette", "data/llm_cassette.jsonl", "cassette served with -llm replay")
+	agentsDir := flag.String("agents", "config/agents", "declarative agents directory (in-process)")
+	speed := flag.Float64("speed", 0, "pace multiplier: 1 keeps the original timing, 10 is ten times faster, 0 sends without waiting")
+	prefix := flag.String("prefix", "", "prefix added to conversation IDs (avoids clashing with live conversations)")
+	timeout := flag.Duration("timeout", time.Minute, "timeout per message")
+	verbose := flag.Bool("v", false, "keep the orchestrator logs (in-process)")
+	flag.Parse()
+
+	_ = godotenv.Load()
+
+	records, skipped, err := loadRecords(*inPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(records) == 0 {
+		log.Fatalf("%s: no records with conversation_id and message (%d lines skipped)", *inPath, skipped)
+	}
+
+	var send sender
+	if *target != "" {
+		send = httpSender(strings.TrimRight(*target, "/"))
+	} else {
+		var client llm.Client
+		if *mode != "none" {
+			if client, err = harness.Client(*mode, *cassettePath); err != nil {
+				log.Fatal(err)
+			}
+		}
+		orch, err := harness.New(client, harness.Config{AgentsDir: *agentsDir, PreRouter: true})
+		if err != nil {
+			log.Fatal(err)
+		}
+		send = orch.Send
+	}
+
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	if !*verbose && *target == "" {
+		log.SetOutput(io.Discard)
+	}
+	logger.Printf("event=replay_started records=%d skipped=%d speed=%g target=%q", len(records), skipped, *speed, *target)
+
+	start := time.Now()
+	results := replay(records, send, *speed, *prefix, *timeout)
+
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+	if err := writeResults(*outPath, results); err != nil {
+		logger.Fatal(err)
+	}
+	logger.Printf("event=replay_finished records=%d errors=%d elapsed=%v out=%s", len(results), failed, time.Since(start), *outPath)
+}
+
+// loadRecords reads the log, skipping lines that are not customer messages
+func loadRecords(path string) ([]Record, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	var records []Record
+	skipped := 0
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(line, &r); err != nil || r.ConversationID == "" || r.Message == "" {
+			skipped++
+			continue
+		}
+		r.seq = len(records) + 1
+		records = append(records, r)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	sortTimed(records)
+	return records, skipped, nil
+}
+
+// sortTimed orders the records that have a timestamp among themselves. Untimed records keep
+// their position in the file, and the stable sort keeps file order for equal timestamps.
+func sortTimed(records []Record) {
+	var (
+		slots []int
+		timed []Record
+	)
+	for i, r := range records {
+		if !r.Timestamp.IsZero() {
+			slots = append(slots, i)
+			timed = append(timed, r)
+		}
+	}
+	sort.SliceStable(timed, func(i, j int) bool {
+		return timed[i].Timestamp.Before(timed[j].Timestamp)
+	})
+	for k, i := range slots {
+		records[i] = timed[k]
+	}
+}
+
+// replay dispatches every record at its (scaled) offset from the first timed record; untimed
+// records go out right away. Messages of a conversation are sent one after another, while
+// different conversations overlap as they did in production.
+func replay(records []Record, send sender, speed float64, prefix string, timeout time.Duration) []Result {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results = make([]Result, 0, len(records))
+		queues  = make(map[string]chan Record)
+	)
+
+	worker := func(ch chan Record) {
+		defer wg.Done()
+		for r := range ch {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			res, latency, err := send(ctx, prefix+r.ConversationID, r.Message)
+			cancel()
+
+			out := Result{
+				Seq:            r.seq,
+				ConversationID: r.ConversationID,
+				Message:        r.Message,
+				Timestamp:      r.Timestamp,
+				Agent:          res.Agent,
+				Action:         res.Action,
+				Reply:          res.Reply,
+				Tool:           res.Tool,
+				LatencyMs:      latency.Milliseconds(),
+			}
+			if err != nil {
+				out.Error = err.Error()
+			}
+			mu.Lock()
+			results = append(results, out)
+			mu.Unlock()
+		}
+	}
+
+	var first time.Time
+	for _, r := range records {
+		if !r.Timestamp.IsZero() {
+			first = r.Timestamp
+			break
+		}
+	}
+
+	start := time.Now()
+	for _, r := range records {
+		if speed > 0 && !r.Timestamp.IsZero() {
+			due := time.Duration(float64(r.Timestamp.Sub(first)) / speed)
+			if wait := due - time.Since(start); wait > 0 {
+				time.Sleep(wait)
+			}
+		}
+
+		ch, ok := queues[r.ConversationID]
+		if !ok {
+			ch = make(chan Record, 64)
+			queues[r.ConversationID] = ch
+			wg.Add(1)
+			go worker(ch)
+		}
+		ch <- r
+	}
+	for _, ch := range queues {
+		close(ch)
+	}
+	wg.Wait()
+
+	// Output follows the log order so two runs can be diffed line by line
+	sort.Slice(results, func(i, j int) bool { return results[i].Seq < results[j].Seq })
+	return results
+}
+
+// httpSender posts messages to a running server
+func httpSender(baseURL string) sender {
+	client := &http.Client{}
+	return func(ctx context.Context, convID, message string) (api.MessageResponse, time.Duration, error) {
+		body, _ := json.Marshal(api.MessageRequest{ConversationID: convID, Message: message})
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/messages", bytes.NewReader(body))
+		if err != nil {
+			return api.MessageResponse{}, 0, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		start := time.Now()
+		res, err := client.Do(req)
+		if err != nil {
+			return api.MessageResponse{}, time.Since(start), err
+		}
+		defer res.Body.Close()
+
+		var out api.MessageResponse
+		if res.StatusCode != http.StatusOK {
+			b, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+			return out, time.Since(start), fmt.Errorf("status %d: %s", res.StatusCode, bytes.TrimSpace(b))
+		}
+		if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+			return out, time.Since(start), errors.New("invalid response: " + err.Error())
+		}
+		return out, time.Since(start), nil
+	}
+}
+
+// writeResults writes one JSON line per replayed message
+func writeResults(path string, results []Result) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, r := range results {
+		if err := enc.Encode(r); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
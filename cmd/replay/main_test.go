@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/api"
+)
+
+func TestLoadRecordsKeepsUntimedInPlace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "in.jsonl")
+	lines := []string{
+		`{"conversation_id":"a","message":"sem hora 1"}`,
+		`{"conversation_id":"b","message":"10:05","timestamp":"2026-10-01T10:00:05Z"}`,
+		`{"conversation_id":"c","message":"sem hora 2"}`,
+		`{"conversation_id":"d","message":"10:01","timestamp":"2026-10-01T10:00:01Z"}`,
+		`not json`,
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	records, skipped, err := loadRecords(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != 1 {
+		t.Errorf("skipped = %d, want 1", skipped)
+	}
+	var got []string
+	for _, r := range records {
+		got = append(got, r.Message)
+	}
+	if want := "sem hora 1|10:01|sem hora 2|10:05"; strings.Join(got, "|") != want {
+		t.Errorf("order = %s, want %s", strings.Join(got, "|"), want)
+	}
+}
+
+func TestReplayPacesFromFirstTimedRecord(t *testing.T) {
+	base := time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)
+	records := []Record{
+		{ConversationID: "a", Message: "sem hora", seq: 1},
+		{ConversationID: "b", Message: "t0", Timestamp: base, seq: 2},
+		{ConversationID: "c", Message: "t0+2s", Timestamp: base.Add(2 * time.Second), seq: 3},
+	}
+	send := func(ctx context.Context, convID, message string) (api.MessageResponse, time.Duration, error) {
+		return api.MessageResponse{Reply: message}, 0, nil
+	}
+
+	// At speed 20 the 2s gap becomes 100ms; an untimed first record must not disable pacing
+	start := time.Now()
+	results := replay(records, send, 20, "", time.Second)
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("replay took %v, want the 100ms gap to be kept", elapsed)
+	}
+	if len(results) != 3 || results[0].Seq != 1 || results[2].Reply != "t0+2s" {
+		t.Errorf("results = %+v", results)
+	}
+}
@@ -1,12 +1,30 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
+	"github.com/bonettibruno/Jota_ProdOps/internal/agents/declarative"
 	"github.com/bonettibruno/Jota_ProdOps/internal/api"
-	"github.com/bonettibruno/Jota_ProdOps/internal/llm/gemini"
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+	"github.com/bonettibruno/Jota_ProdOps/internal/experiment"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm/cassette"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm/provider"
+	"github.com/bonettibruno/Jota_ProdOps/internal/med"
+	"github.com/bonettibruno/Jota_ProdOps/internal/prerouter"
+	"github.com/bonettibruno/Jota_ProdOps/internal/prompt"
+	"github.com/bonettibruno/Jota_ProdOps/internal/shadow"
+	"github.com/bonettibruno/Jota_ProdOps/internal/summary"
+	"github.com/bonettibruno/Jota_ProdOps/internal/tools"
 	"github.com/joho/godotenv"
 )
 
@@ -20,21 +38,293 @@ func main() {
 	}
 	addr := ":" + port
 
-	// Initialize Gemini LLM client
-	g, err := gemini.New()
+	// -llm-mode switches between the live provider, recording its answers and replaying a cassette
+	llmMode := flag.String("llm-mode", "live", "LLM mode: live, record or replay")
+	cassettePath := flag.String("cassette", "data/llm_cassette.jsonl", "cassette file used by record and replay modes")
+	flag.Parse()
+
+	mode, err := cassette.ParseMode(*llmMode)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Initialize the LLM client selected by LLM_PROVIDER; without one the server still starts
+	// and serves the deterministic paths (pre-router, escalation, human desk)
+	client, err := newLLMClient(mode, *cassettePath)
+	if err != nil {
+		if mode != cassette.Live {
+			log.Fatal(err)
+		}
+		log.Printf("event=llm_unavailable provider=%s error=%v", provider.Name(), err)
+	} else {
+		api.SetLLMClient(client)
+	}
+
+	// Select conversation storage backend (memory | file)
+	store, err := newStore()
+	if err != nil {
+		log.Fatal(err)
+	}
+	api.SetStore(store)
+
+	// Rolling summarization: older turns are condensed before the history limit drops them
+	if os.Getenv("SUMMARY_ENABLED") != "false" {
+		keep := historyLimit / 2
+		if v := os.Getenv("SUMMARY_KEEP"); v != "" {
+			keep, err = strconv.Atoi(v)
+			if err != nil || keep < 1 || keep >= historyLimit-1 {
+				log.Fatalf("invalid SUMMARY_KEEP: %q (must be between 1 and %d)", v, historyLimit-2)
+			}
+		}
+		api.SetSummarizer(summary.New(client), api.SummaryConfig{Trigger: historyLimit - 1, Keep: keep})
+	}
+
+	// MED case management: persistent cases behind the abrir_med / consultar_med tools
+	cases, err := newMEDStore()
+	if err != nil {
+		log.Fatal(err)
+	}
+	tools.Register(med.NewAbrirTool(cases))
+	tools.Register(med.NewConsultarTool(cases))
+	api.SetMEDStore(cases)
+
+	// Session expiry: idle conversations are reset and swept in background
+	ttl, err := durationEnv("SESSION_IDLE_TTL", 24*time.Hour)
+	if err != nil {
+		log.Fatal(err)
+	}
+	interval, err := durationEnv("SESSION_SWEEP_INTERVAL", time.Minute)
+	if err != nil {
+		log.Fatal(err)
+	}
+	api.SetSessionTTL(ttl)
+	core.StartSweeper(context.Background(), store, ttl, interval, api.ExpireSession)
+
+	// Deterministic pre-router for critical intents (built-in rules unless PREROUTER_RULES is set)
+	pr, err := newPreRouter()
+	if err != nil {
+		log.Fatal(err)
+	}
+	api.SetPreRouter(pr)
+
+	// Optional LLM router stage for triage of new conversations and topic shifts
+	threshold := 0.7
+	if v := os.Getenv("ROUTER_CONFIDENCE_THRESHOLD"); v != "" {
+		threshold, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			log.Fatalf("invalid ROUTER_CONFIDENCE_THRESHOLD: %v", err)
+		}
+	}
+	api.SetRouterConfig(api.RouterConfig{
+		Enabled:   os.Getenv("ROUTER_ENABLED") == "true",
+		Threshold: threshold,
+	})
+
+	// Token budget for the dynamic part of every agent prompt (history, RAG, notes, slots)
+	if v := os.Getenv("PROMPT_TOKEN_BUDGET"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Fatalf("invalid PROMPT_TOKEN_BUDGET: %q", v)
+		}
+		prompt.SetTokenBudget(n)
+	}
+
+	// Prompt templates: embedded defaults, optionally overridden (and hot-reloaded) from PROMPTS_DIR
+	if dir := os.Getenv("PROMPTS_DIR"); dir != "" {
+		if err := prompt.SetDir(dir); err != nil {
+			log.Fatal(err)
+		}
+		reload, err := durationEnv("PROMPTS_RELOAD_INTERVAL", 10*time.Second)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if reload > 0 {
+			prompt.Watch(context.Background(), reload)
+		}
+	}
+	for _, t := range prompt.Templates() {
+		log.Printf("event=prompt_loaded template=%s source=%s", t.ID(), t.Source)
+	}
+
+	// A/B experiments on prompt templates and models per agent
+	if path := os.Getenv("EXPERIMENTS_FILE"); path != "" {
+		exps, err := experiment.LoadFile(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, e := range exps.All() {
+			for _, v := range e.Variants {
+				if v.Template == "" {
+					continue
+				}
+				if _, err := prompt.Lookup(v.Template); err != nil {
+					log.Fatalf("experiment %s, variant %s: %v", e.Name, v.Name, err)
+				}
+			}
+			log.Printf("event=experiment_loaded experiment=%s agent=%s variants=%d", e.Name, e.Agent, len(e.Variants))
+		}
+		api.SetExperiments(exps)
+	}
+
+	// Load declarative agents (config/agents/*.json) into the registry
+	agentsDir := os.Getenv("AGENTS_DIR")
+	if agentsDir == "" {
+		agentsDir = "config/agents"
+	}
+	names, err := declarative.LoadDir(agentsDir)
 	if err != nil {
 		log.Fatal(err)
 	}
+	log.Printf("event=declarative_agents_loaded dir=%s agents=%v", agentsDir, names)
 
-	// Set global LLM client for handlers
-	api.SetLLMClient(g)
+	// Shadow mode: candidate brains (declarative "shadow_of") run next to production agents
+	if os.Getenv("SHADOW_ENABLED") != "false" && client != nil {
+		runner, err := shadow.NewRunner(client, shadow.Config{
+			Concurrency: 4,
+			Path:        os.Getenv("SHADOW_LOG_PATH"),
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		api.SetShadowRunner(runner)
+	}
 
 	// Route definitions
 	mux := http.NewServeMux()
-	mux.HandleFunc("/health", api.HealthHandler)     // Service health check
-	mux.HandleFunc("/messages", api.MessagesHandler) // Main chat and orchestration endpoint
-	mux.HandleFunc("/metrics", api.MetricsHandler)   // Telemetry and ProdOps KPIs
+	mux.HandleFunc("/health", api.HealthHandler)                              // Service health check
+	mux.HandleFunc("/messages", api.MessagesHandler)                          // Main chat and orchestration endpoint
+	mux.HandleFunc("/metrics", api.MetricsHandler)                            // Telemetry and ProdOps KPIs
+	mux.HandleFunc("/med/cases", api.MEDCasesHandler)                         // MED case listing
+	mux.HandleFunc("/med/cases/{id}", api.MEDCaseHandler)                     // MED case status query and update
+	mux.HandleFunc("/escalations", api.EscalationsHandler)                    // Human desk queue
+	mux.HandleFunc("/escalations/{id}", api.EscalationHandler)                // Single escalation ticket
+	mux.HandleFunc("/escalations/{id}/{action}", api.EscalationActionHandler) // claim, reply and close
+	mux.HandleFunc("/conversations/{id}/outbox", api.OutboxHandler)           // Pending operator messages
+	mux.HandleFunc("/conversations/{id}/mode", api.ModeHandler)               // Bot pause / human takeover
+	mux.HandleFunc("/prompts", api.PromptsHandler)                            // Active prompt templates and versions
+	mux.HandleFunc("/shadow/report", api.ShadowReportHandler)                 // Production vs shadow agreement
+	mux.HandleFunc("/shadow/records", api.ShadowRecordsHandler)               // Production vs shadow plan pairs
 
 	log.Printf("Server running on %s", addr)
 	log.Fatal(http.ListenAndServe(addr, mux))
 }
+
+// historyLimit is the maximum number of messages kept verbatim per conversation
+const historyLimit = 20
+
+// newStore builds the conversation store configured via STORE_BACKEND and STORE_PATH
+func newStore() (core.Store, error) {
+	switch backend := os.Getenv("STORE_BACKEND"); backend {
+	case "", "memory":
+		log.Printf("event=store_ready backend=memory")
+		return core.NewConversationStore(historyLimit), nil
+	case "file":
+		path := os.Getenv("STORE_PATH")
+		if path == "" {
+			path = "data/conversations.json"
+		}
+		s, err := core.NewFileStore(path, historyLimit)
+		if err != nil {
+			return nil, err
+		}
+		flush, err := durationEnv("STORE_FLUSH_INTERVAL", time.Second)
+		if err != nil {
+			return nil, err
+		}
+		s.SetFlushInterval(flush)
+		go flushOnSignal(s)
+		log.Printf("event=store_ready backend=file path=%s flush_interval=%v", path, flush)
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown STORE_BACKEND %q", backend)
+	}
+}
+
+// newLLMClient builds the LLM client for the given mode: the LLM_PROVIDER client (live),
+// the same client wrapped by a cassette recorder (record) or a cassette player (replay)
+func newLLMClient(mode cassette.Mode, path string) (llm.Client, error) {
+	if mode == cassette.Replay {
+		p, err := cassette.Load(path)
+		if err != nil {
+			return nil, fmt.Errorf("load cassette: %w", err)
+		}
+		log.Printf("event=llm_ready mode=replay cassette=%s entries=%d", path, p.Len())
+		return p, nil
+	}
+
+	client, err := provider.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if mode == cassette.Live {
+		log.Printf("event=llm_ready mode=live provider=%s", provider.Name())
+		return client, nil
+	}
+
+	rec, err := cassette.NewRecorder(client, path)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("event=llm_ready mode=record provider=%s cassette=%s", provider.Name(), path)
+	return rec, nil
+}
+
+// flushOnSignal writes batched store changes before the process stops
+func flushOnSignal(s *core.FileStore) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	sig := <-ch
+	s.Flush()
+	log.Printf("event=store_flushed signal=%v", sig)
+	os.Exit(0)
+}
+
+// durationEnv parses a time.Duration env var (e.g. "30m"), returning def when unset
+func durationEnv(key string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
+
+// newPreRouter builds the pre-router from PREROUTER_ENABLED, PREROUTER_RULES and PREROUTER_THRESHOLD
+func newPreRouter() (*prerouter.PreRouter, error) {
+	if os.Getenv("PREROUTER_ENABLED") == "false" {
+		log.Printf("event=prerouter_disabled")
+		return nil, nil
+	}
+
+	threshold := 1.0
+	if v := os.Getenv("PREROUTER_THRESHOLD"); v != "" {
+		t, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PREROUTER_THRESHOLD: %w", err)
+		}
+		threshold = t
+	}
+
+	path := os.Getenv("PREROUTER_RULES")
+	if path == "" {
+		log.Printf("event=prerouter_ready rules=builtin threshold=%.2f", threshold)
+		return prerouter.New(prerouter.DefaultRules, threshold)
+	}
+
+	log.Printf("event=prerouter_ready rules=%s threshold=%.2f", path, threshold)
+	return prerouter.LoadFile(path, threshold)
+}
+
+// newMEDStore builds the MED case store; cases are persisted when MED_STORE_PATH is set
+// or when the conversation store is file-backed
+func newMEDStore() (*med.Store, error) {
+	path := os.Getenv("MED_STORE_PATH")
+	if path == "" && os.Getenv("STORE_BACKEND") == "file" {
+		path = "data/med_cases.json"
+	}
+	log.Printf("event=med_store_ready path=%q", path)
+	return med.NewStore(path)
+}
@@ -0,0 +1,11 @@
+// Package agents links every specialist agent into the binary.
+// Each agent package registers itself in core via init; adding a new
+// agent only requires its package and a blank import below.
+package agents
+
+import (
+	_ "github.com/bonettibruno/Jota_ProdOps/internal/agents/atendimento"
+	_ "github.com/bonettibruno/Jota_ProdOps/internal/agents/criacaoconta"
+	_ "github.com/bonettibruno/Jota_ProdOps/internal/agents/golpemed"
+	_ "github.com/bonettibruno/Jota_ProdOps/internal/agents/openfinance"
+)
@@ -7,43 +7,40 @@ import (
 
 	"github.com/bonettibruno/Jota_ProdOps/internal/core"
 	"github.com/bonettibruno/Jota_ProdOps/internal/llm"
+	"github.com/bonettibruno/Jota_ProdOps/internal/prompt"
 )
 
+// Name is the technical identifier of the General Assistance agent
+const Name = core.DefaultAgent
+
 type Brain struct{}
 
+func init() {
+	core.RegisterAgent(core.AgentSpec{
+		Name:        Name,
+		Description: "Para dúvidas gerais, saudações e assuntos que não se encaixam nos outros especialistas.",
+		Actions:     []string{"reply", "ask", "change_agent"},
+		Brain:       &Brain{},
+	})
+}
+
 // Run executes the General Assistance (Aline) agent logic
-func (b *Brain) Run(ctx context.Context, client any, traceID string, history []core.ChatMessage, userMessage string, ragContext string) (core.ActionPlan, error) {
+func (b *Brain) Run(ctx context.Context, client any, in core.BrainInput) (core.ActionPlan, error) {
 	// Cast generic client to the specific LLM client interface
 	llmClient := client.(llm.Client)
 
-	system := fmt.Sprintf(`Você é a Aline, a assistente virtual inteligente do Jota. 
-Seu papel é recepcionar o cliente e decidir se você resolve o problema ou se transfere para um especialista.
-
-REGRAS DE TRANSFERÊNCIA (CRÍTICO):
-Se identificar que o assunto é específico, você DEVE responder com action="change_agent" e preencher o campo "change_agent" com EXATAMENTE um dos nomes abaixo:
-
-1. "criacao_conta" -> Uso: Abertura de conta, problemas com selfie, documentos, erro de CPF/CNPJ no cadastro, conta PF ou PJ.
-2. "open_finance" -> Uso: Conectar outros bancos, compartilhar dados, problemas com o link do Open Finance.
-3. "golpe_med"     -> Uso: Cliente foi roubado, caiu em golpe, Pix fraudulento, quer usar o MED.
+	spec, _ := core.LookupAgent(Name)
 
-FORMATO DE RESPOSTA (JSON APENAS):
-{
-  "action": "reply | ask | change_agent",
-  "message": "Sua mensagem empática para o cliente aqui",
-  "change_agent": "criacao_conta | open_finance | golpe_med",
-  "confidence": 1.0
-}
-
-IMPORTANTE:
-- Se o assunto for geral (saudações, dúvidas simples), responda você mesma usando action="reply".
-- NUNCA use "atendimento_geral" no campo change_agent.
-- Se o cliente mudar de assunto (ex: estava falando de golpe e agora quer abrir conta), transfira imediatamente.
+	// History, handoff note and RAG fitted to the token budget
+	p := prompt.Assemble(in)
 
-Base de conhecimento (RAG):
-%s`, ragContext)
+	system, version, err := prompt.System(ctx, spec, p.RAG)
+	if err != nil {
+		return core.ActionPlan{}, err
+	}
 
 	// Call LLM generator
-	raw, err := llmClient.GenerateText(ctx, traceID, system, userMessage)
+	raw, err := llmClient.GenerateText(ctx, in.TraceID, system, p.User)
 	if err != nil {
 		return core.ActionPlan{}, err
 	}
@@ -52,6 +49,7 @@ Base de conhecimento (RAG):
 	if err := json.Unmarshal([]byte(raw), &plan); err != nil {
 		return core.ActionPlan{}, fmt.Errorf("failed to decode Aline's JSON: %w", err)
 	}
+	plan.PromptVersion = version
 
 	return plan, nil
 }
@@ -0,0 +1,123 @@
+package declarative
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm"
+	"github.com/bonettibruno/Jota_ProdOps/internal/prompt"
+)
+
+// Config is the on-disk definition of a specialist agent
+type Config struct {
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Persona      string   `json:"persona"`
+	SystemPrompt string   `json:"system_prompt"`
+	Actions      []string `json:"actions"`
+	Handoffs     []string `json:"handoffs"`
+	Tools        []string `json:"tools"`
+	Form         string   `json:"form"`
+	KBSections   []string `json:"kb_sections"`
+	Security     bool     `json:"security"`
+	// ShadowOf runs this config as a shadow candidate of a production agent instead of registering it
+	ShadowOf string `json:"shadow_of"`
+}
+
+// Brain is a generic agent whose behavior is fully defined by a Config
+type Brain struct {
+	cfg  Config
+	tmpl *prompt.Template
+}
+
+// NewBrain validates the config and compiles its system prompt template
+func NewBrain(cfg Config) (*Brain, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("agent config: name is required")
+	}
+	if cfg.SystemPrompt == "" {
+		return nil, fmt.Errorf("agent %s: system_prompt is required", cfg.Name)
+	}
+	if len(cfg.Actions) == 0 {
+		cfg.Actions = []string{"reply", "ask", "change_agent"}
+	}
+
+	tmpl, err := prompt.Compile(cfg.Name, "config", cfg.SystemPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("agent %s: invalid system_prompt template: %w", cfg.Name, err)
+	}
+
+	return &Brain{cfg: cfg, tmpl: tmpl}, nil
+}
+
+// Spec returns the registry entry for this agent
+func (b *Brain) Spec() core.AgentSpec {
+	return core.AgentSpec{
+		Name:        b.cfg.Name,
+		Description: b.cfg.Description,
+		Actions:     b.cfg.Actions,
+		Handoffs:    b.cfg.Handoffs,
+		Tools:       b.cfg.Tools,
+		Form:        b.cfg.Form,
+		KBSections:  b.cfg.KBSections,
+		Security:    b.cfg.Security,
+		Brain:       b,
+	}
+}
+
+// Run executes the configured agent logic
+func (b *Brain) Run(ctx context.Context, client any, in core.BrainInput) (core.ActionPlan, error) {
+	// Type Assertion: retrieve the specific LLM client interface
+	llmClient := client.(llm.Client)
+
+	p := prompt.Assemble(in)
+	systemPrompt, version, err := b.buildSystemPrompt(ctx, p.RAG)
+	if err != nil {
+		return core.ActionPlan{}, err
+	}
+
+	// Execute LLM text generation
+	raw, err := llmClient.GenerateText(ctx, in.TraceID, systemPrompt, p.User)
+	if err != nil {
+		return core.ActionPlan{}, err
+	}
+
+	var plan core.ActionPlan
+	if err := json.Unmarshal([]byte(raw), &plan); err != nil {
+		return core.ActionPlan{}, fmt.Errorf("failed to decode ActionPlan JSON: %w", err)
+	}
+	plan.PromptVersion = version
+
+	return plan, nil
+}
+
+// buildSystemPrompt renders the agent's template; a template set in ctx (experiments) or a
+// PROMPTS_DIR file of the same name takes precedence
+func (b *Brain) buildSystemPrompt(ctx context.Context, ragContext string) (string, string, error) {
+	t := b.tmpl
+	if name := prompt.TemplateFrom(ctx); name != "" {
+		override, err := prompt.Lookup(name)
+		if err != nil {
+			return "", "", fmt.Errorf("agent %s: %w", b.cfg.Name, err)
+		}
+		t = override
+	} else if override, err := prompt.Lookup(b.cfg.Name); err == nil {
+		t = override
+	}
+
+	// A shadow candidate sees the same transfer rules and identity as the agent it shadows
+	spec := b.Spec()
+	if b.cfg.ShadowOf != "" {
+		spec.Name = b.cfg.ShadowOf
+	}
+	data := prompt.DataFor(spec, ragContext)
+	data.Persona = b.cfg.Persona
+
+	text, err := t.Render(data)
+	if err != nil {
+		return "", "", fmt.Errorf("agent %s: %w", b.cfg.Name, err)
+	}
+	return text, t.ID(), nil
+}
@@ -0,0 +1,115 @@
+package declarative
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm/fake"
+)
+
+func TestNewBrainValidates(t *testing.T) {
+	if _, err := NewBrain(Config{SystemPrompt: "x"}); err == nil {
+		t.Error("config without name accepted")
+	}
+	if _, err := NewBrain(Config{Name: "x"}); err == nil {
+		t.Error("config without system_prompt accepted")
+	}
+	if _, err := NewBrain(Config{Name: "x", SystemPrompt: "{{.Persona"}); err == nil {
+		t.Error("broken template accepted")
+	}
+
+	b, err := NewBrain(Config{Name: "x", SystemPrompt: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(b.Spec().Actions, ","); got != "reply,ask,change_agent" {
+		t.Errorf("default actions = %s", got)
+	}
+}
+
+func TestRunRendersPromptAndDecodesPlan(t *testing.T) {
+	b, err := NewBrain(Config{
+		Name:         "decl_run",
+		Persona:      "Você é o Especialista de Teste.",
+		SystemPrompt: "{{.Persona}}\nAções: {{.Actions}}\nBase:\n{{.RAG}}",
+		Actions:      []string{"reply", "escalate"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	llm := fake.New().On(fake.Rule{Response: fake.Plan(core.ActionPlan{Action: "reply", Message: "Olá!"})})
+
+	plan, err := b.Run(context.Background(), llm, core.BrainInput{TraceID: "t1", UserMessage: "oi", RAGContext: "taxa zero"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Action != "reply" || plan.Message != "Olá!" || plan.PromptVersion == "" {
+		t.Errorf("plan = %+v", plan)
+	}
+	system := llm.Calls()[0].System
+	for _, want := range []string{"Você é o Especialista de Teste.", "reply", "escalate", "taxa zero"} {
+		if !strings.Contains(system, want) {
+			t.Errorf("system prompt missing %q:\n%s", want, system)
+		}
+	}
+
+	bad := fake.New().On(fake.Rule{Response: "não sou JSON"})
+	if _, err := b.Run(context.Background(), bad, core.BrainInput{UserMessage: "oi"}); err == nil {
+		t.Error("invalid JSON accepted as a plan")
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	if names, err := LoadDir(filepath.Join(t.TempDir(), "missing")); err != nil || names != nil {
+		t.Errorf("missing dir = %v, %v", names, err)
+	}
+
+	dir := t.TempDir()
+	write(t, dir, "a.json", `{"name": "decl_load", "description": "teste", "system_prompt": "{{.Persona}}"}`)
+	write(t, dir, "b.json", `{"name": "decl_load_v2", "shadow_of": "decl_load", "system_prompt": "v2"}`)
+	write(t, dir, "notes.txt", `ignorado`)
+
+	names, err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, ",") != "decl_load,decl_load_v2" {
+		t.Errorf("names = %v", names)
+	}
+	if _, ok := core.LookupAgent("decl_load"); !ok {
+		t.Error("agent not registered")
+	}
+	if sh, ok := core.LookupShadow("decl_load"); !ok || sh.Name != "decl_load_v2" {
+		t.Errorf("shadow = %+v, %t", sh, ok)
+	}
+
+	// Loading the same agent twice is a configuration error
+	if _, err := LoadDir(dir); err == nil {
+		t.Error("duplicate agent accepted")
+	}
+}
+
+func TestLoadDirRejectsBadFiles(t *testing.T) {
+	cases := map[string]string{
+		"unknown field":  `{"name": "decl_typo", "system_prompt": "x", "acitons": ["reply"]}`,
+		"unknown shadow": `{"name": "decl_orphan", "shadow_of": "decl_nobody", "system_prompt": "x"}`,
+	}
+	for name, body := range cases {
+		dir := t.TempDir()
+		write(t, dir, "agent.json", body)
+		if _, err := LoadDir(dir); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+func write(t *testing.T, dir, name, body string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,87 @@
+package declarative
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+)
+
+// LoadDir registers every *.json agent definition found in dir and returns their names.
+// Definitions with "shadow_of" are attached as shadow candidates of that agent.
+// A missing directory is not an error: declarative agents are optional.
+func LoadDir(dir string) ([]string, error) {
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	type pending struct {
+		file  string
+		cfg   Config
+		brain *Brain
+	}
+	var names []string
+	var candidates []pending
+
+	for _, f := range files {
+		cfg, err := loadFile(f)
+		if err != nil {
+			return names, err
+		}
+
+		brain, err := NewBrain(cfg)
+		if err != nil {
+			return names, fmt.Errorf("%s: %w", f, err)
+		}
+		// Shadows are attached once every agent is registered
+		if cfg.ShadowOf != "" {
+			candidates = append(candidates, pending{file: f, cfg: cfg, brain: brain})
+			continue
+		}
+		if _, exists := core.LookupAgent(cfg.Name); exists {
+			return names, fmt.Errorf("%s: agent %q is already registered", f, cfg.Name)
+		}
+
+		core.RegisterAgent(brain.Spec())
+		names = append(names, cfg.Name)
+	}
+
+	for _, c := range candidates {
+		if _, exists := core.LookupAgent(c.cfg.ShadowOf); !exists {
+			return names, fmt.Errorf("%s: shadow_of: unknown agent %q", c.file, c.cfg.ShadowOf)
+		}
+		if _, dup := core.LookupShadow(c.cfg.ShadowOf); dup {
+			return names, fmt.Errorf("%s: agent %q already has a shadow", c.file, c.cfg.ShadowOf)
+		}
+		core.RegisterShadow(c.cfg.ShadowOf, core.Shadow{Name: c.cfg.Name, Brain: c.brain})
+		names = append(names, c.cfg.Name)
+	}
+	return names, nil
+}
+
+// loadFile decodes a single agent definition, rejecting unknown fields to catch typos
+func loadFile(path string) (Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Config{}, err
+	}
+	defer f.Close()
+
+	var cfg Config
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
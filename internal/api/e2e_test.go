@@ -0,0 +1,435 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/api"
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+	"github.com/bonettibruno/Jota_ProdOps/internal/escalation"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm/fake"
+	"github.com/bonettibruno/Jota_ProdOps/internal/med"
+	"github.com/bonettibruno/Jota_ProdOps/internal/prerouter"
+	"github.com/bonettibruno/Jota_ProdOps/internal/summary"
+	"github.com/bonettibruno/Jota_ProdOps/internal/tools"
+)
+
+// System prompt fragments that identify each specialist in the fake rules
+const (
+	atendimentoPrompt = "Você é a Aline"
+	golpePrompt       = "Especialista em Segurança e Golpe MED"
+	openFinancePrompt = "Especialista em Open Finance"
+)
+
+// env is a server wired like cmd/server but backed by the fake LLM and in-memory stores
+type env struct {
+	t     *testing.T
+	srv   *httptest.Server
+	llm   *fake.Client
+	store *core.ConversationStore
+	queue *escalation.Queue
+}
+
+func newEnv(t *testing.T, llm *fake.Client, withPreRouter bool) *env {
+	t.Helper()
+
+	store := core.NewConversationStore(20)
+	queue := escalation.NewQueue()
+	cases, err := med.NewStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools.Register(med.NewAbrirTool(cases))
+	tools.Register(med.NewConsultarTool(cases))
+
+	var pr *prerouter.PreRouter
+	if withPreRouter {
+		if pr, err = prerouter.New(prerouter.DefaultRules, 1.0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	api.SetLLMClient(llm)
+	api.SetStore(store)
+	api.SetEscalationQueue(queue)
+	api.SetMEDStore(cases)
+	api.SetPreRouter(pr)
+	api.SetRouterConfig(api.RouterConfig{})
+	api.SetSummarizer(nil, api.SummaryConfig{})
+	api.SetSessionTTL(0)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/messages", api.MessagesHandler)
+	mux.HandleFunc("/escalations", api.EscalationsHandler)
+	mux.HandleFunc("/escalations/{id}/{action}", api.EscalationActionHandler)
+	mux.HandleFunc("/conversations/{id}/mode", api.ModeHandler)
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return &env{t: t, srv: srv, llm: llm, store: store, queue: queue}
+}
+
+// send posts a customer message and decodes the response
+func (e *env) send(convID, msg string) api.MessageResponse {
+	e.t.Helper()
+
+	body, _ := json.Marshal(api.MessageRequest{ConversationID: convID, Message: msg})
+	res, err := http.Post(e.srv.URL+"/messages", "application/json", bytes.NewReader(body))
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		e.t.Fatalf("POST /messages: status %d", res.StatusCode)
+	}
+
+	var out api.MessageResponse
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		e.t.Fatal(err)
+	}
+	return out
+}
+
+func reply(msg string) string {
+	return fake.Plan(core.ActionPlan{Action: "reply", Message: msg, Confidence: 0.9})
+}
+
+func TestReplyKeepsHistory(t *testing.T) {
+	llm := fake.New().On(fake.Rule{System: atendimentoPrompt, Response: reply("Olá! Como posso ajudar?")})
+	e := newEnv(t, llm, false)
+
+	first := e.send("c-reply", "oi")
+	if first.Action != "reply" || first.Agent != core.DefaultAgent || first.Reply != "Olá! Como posso ajudar?" {
+		t.Fatalf("unexpected first response: %+v", first)
+	}
+	if first.PromptVersion != "atendimento_geral@v1" {
+		t.Errorf("prompt version = %q", first.PromptVersion)
+	}
+
+	second := e.send("c-reply", "quero saber do meu cartão")
+	if second.HistoryCount != 4 {
+		t.Errorf("history count = %d, want 4", second.HistoryCount)
+	}
+
+	calls := llm.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("llm calls = %d, want 2", len(calls))
+	}
+	if !strings.Contains(calls[1].User, "Cliente: oi") || !strings.Contains(calls[1].User, "quero saber do meu cartão") {
+		t.Errorf("second prompt misses the history:\n%s", calls[1].User)
+	}
+}
+
+func TestHandoffToSpecialist(t *testing.T) {
+	llm := fake.New().
+		On(fake.Rule{System: atendimentoPrompt, Response: fake.Plan(core.ActionPlan{
+			Action: "change_agent", ChangeAgent: "open_finance", HandoffReason: "cliente quer conectar outro banco", Confidence: 0.9,
+		})}).
+		On(fake.Rule{System: openFinancePrompt, Response: reply("Vamos conectar sua conta pelo Open Finance.")})
+	e := newEnv(t, llm, false)
+
+	res := e.send("c-handoff", "quero trazer meus dados de outro banco")
+	if res.Agent != "open_finance" || res.Action != "reply" {
+		t.Fatalf("unexpected response: %+v", res)
+	}
+	if agent, _ := e.store.GetAgent("c-handoff"); agent != "open_finance" {
+		t.Errorf("assigned agent = %q", agent)
+	}
+
+	calls := llm.Calls()
+	if len(calls) != 2 || !strings.Contains(calls[1].User, "NOTA DE TRANSFERÊNCIA") {
+		t.Fatalf("specialist did not receive the handoff note: %+v", calls)
+	}
+
+	// The next message goes straight to the specialist
+	e.send("c-handoff", "e agora?")
+	if calls = llm.Calls(); !strings.Contains(calls[2].System, openFinancePrompt) {
+		t.Errorf("follow-up ran on the wrong agent")
+	}
+}
+
+func TestHandoffLoopResolves(t *testing.T) {
+	llm := fake.New().
+		On(fake.Rule{System: atendimentoPrompt, Response: fake.Plan(core.ActionPlan{
+			Action: "change_agent", ChangeAgent: "open_finance", Confidence: 0.9,
+		})}).
+		On(fake.Rule{System: openFinancePrompt, User: "Não transfira novamente", Response: reply("Resolvido por aqui.")}).
+		On(fake.Rule{System: openFinancePrompt, Response: fake.Plan(core.ActionPlan{
+			Action: "change_agent", ChangeAgent: "atendimento_geral", Confidence: 0.3,
+		})})
+	e := newEnv(t, llm, false)
+
+	res := e.send("c-loop", "dúvida sobre open finance")
+	if res.Agent != "open_finance" || res.Reply != "Resolvido por aqui." {
+		t.Fatalf("loop not resolved to the most confident target: %+v", res)
+	}
+}
+
+func TestEscalationHandsOverToHuman(t *testing.T) {
+	llm := fake.New().On(fake.Rule{System: golpePrompt, Response: fake.Plan(core.ActionPlan{
+		Action: "escalate", Message: "Vou te passar para um atendente.", HandoffReason: "cliente pediu humano",
+	})})
+	e := newEnv(t, llm, false)
+	e.store.SetAgent("c-esc", "golpe_med")
+
+	res := e.send("c-esc", "quero falar com uma pessoa")
+	if res.Action != "escalate" {
+		t.Fatalf("action = %q, want escalate", res.Action)
+	}
+	tickets := e.queue.List("")
+	if len(tickets) != 1 || tickets[0].ConversationID != "c-esc" || tickets[0].Reason != "cliente pediu humano" {
+		t.Fatalf("unexpected tickets: %+v", tickets)
+	}
+
+	// While a human is in charge the bot stays silent
+	next := e.send("c-esc", "alô?")
+	if next.Action != "forwarded" || next.Reply != "" {
+		t.Errorf("unexpected response in human mode: %+v", next)
+	}
+	if n := len(llm.Calls()); n != 1 {
+		t.Errorf("llm calls = %d, want 1", n)
+	}
+}
+
+func TestSecurityRuleEscalatesWithoutLLM(t *testing.T) {
+	llm := fake.New()
+	e := newEnv(t, llm, true)
+
+	res := e.send("c-hack", "um hacker invadiu minha conta")
+	if res.Action != "escalate" || res.Agent != "golpe_med" {
+		t.Fatalf("unexpected response: %+v", res)
+	}
+	if n := len(llm.Calls()); n != 0 {
+		t.Errorf("llm calls = %d, want 0", n)
+	}
+	if tickets := e.queue.List(""); len(tickets) != 1 || tickets[0].Priority != escalation.PriorityHigh {
+		t.Errorf("unexpected tickets: %+v", tickets)
+	}
+}
+
+func TestToolCallRoundTrip(t *testing.T) {
+	llm := fake.New().
+		On(fake.Rule{System: golpePrompt, User: "RESULTADO DA TOOL", Response: reply("Você não tem processos MED abertos.")}).
+		On(fake.Rule{System: golpePrompt, Response: fake.Plan(core.ActionPlan{
+			Action: "call_api", Tool: "consultar_med", Confidence: 0.9,
+		})})
+	e := newEnv(t, llm, false)
+	e.store.SetAgent("c-tool", "golpe_med")
+
+	res := e.send("c-tool", "como está meu pedido de devolução?")
+	// A read-only query is a plain answer: the tool is reported, the security banner is not added
+	if res.Action != "reply" || res.Tool != "consultar_med" || res.Reply != "Você não tem processos MED abertos." {
+		t.Fatalf("unexpected response: %+v", res)
+	}
+	if n := len(llm.Calls()); n != 2 {
+		t.Errorf("llm calls = %d, want 2", n)
+	}
+}
+
+func TestOpenMEDAddsProtocolBanner(t *testing.T) {
+	llm := fake.New().
+		On(fake.Rule{System: golpePrompt, User: "RESULTADO DA TOOL", Response: reply("Seu MED foi aberto.")}).
+		On(fake.Rule{System: golpePrompt, Response: fake.Plan(core.ActionPlan{
+			Action: "call_api", Tool: "abrir_med", Confidence: 0.9,
+		})})
+	e := newEnv(t, llm, false)
+	e.store.SetAgent("c-abrir", "golpe_med")
+
+	res := e.send("c-abrir", "paguei R$ 250,00 ontem para a chave golpista@gmail.com, B.O. 2026/12345")
+	if res.Action != "reply" || res.Tool != "abrir_med" || res.Reply != "Seu MED foi aberto.\n\n[Protocolo de Segurança Ativado]" {
+		t.Fatalf("unexpected response: %+v", res)
+	}
+}
+
+func TestRepeatedToolCallIsNotExecuted(t *testing.T) {
+	llm := fake.New().On(fake.Rule{System: golpePrompt, Response: fake.Plan(core.ActionPlan{
+		Action: "call_api", Tool: "consultar_med", Confidence: 0.9,
+	})})
+	e := newEnv(t, llm, false)
+	e.store.SetAgent("c-repeat", "golpe_med")
+
+	res := e.send("c-repeat", "e o meu MED?")
+	if res.Action != "reply" || !strings.Contains(res.Reply, "problema técnico") || strings.Contains(res.Reply, "Protocolo") {
+		t.Fatalf("unexpected response: %+v", res)
+	}
+	if n := len(llm.Calls()); n != 2 {
+		t.Errorf("llm calls = %d, want 2", n)
+	}
+}
+
+func TestBrainErrorFallsBack(t *testing.T) {
+	llm := fake.New().On(fake.Rule{Err: errors.New("quota exceeded")})
+	e := newEnv(t, llm, false)
+
+	res := e.send("c-err", "oi")
+	if res.Action != "reply" || !strings.Contains(res.Reply, "problema técnico") {
+		t.Fatalf("unexpected response: %+v", res)
+	}
+	if n := len(e.queue.List("")); n != 0 {
+		t.Errorf("tickets = %d, want 0", n)
+	}
+}
+
+func TestPreRoutedBrainErrorEscalates(t *testing.T) {
+	llm := fake.New().On(fake.Rule{System: golpePrompt, Err: errors.New("timeout")})
+	e := newEnv(t, llm, true)
+
+	res := e.send("c-prerr", "quero abrir um MED")
+	if res.Action != "escalate" || res.Agent != "golpe_med" {
+		t.Fatalf("unexpected response: %+v", res)
+	}
+	if n := len(e.queue.List("")); n != 1 {
+		t.Errorf("tickets = %d, want 1", n)
+	}
+}
+
+func TestIdleTimeoutKeepsHumanTakeover(t *testing.T) {
+	llm := fake.New().On(fake.Rule{System: golpePrompt, Response: fake.Plan(core.ActionPlan{
+		Action: "escalate", Message: "Vou te passar para um atendente.", HandoffReason: "cliente pediu humano",
+	})})
+	e := newEnv(t, llm, false)
+	e.store.SetAgent("c-idle", "golpe_med")
+	e.send("c-idle", "quero falar com uma pessoa")
+
+	api.SetSessionTTL(time.Millisecond)
+	t.Cleanup(func() { api.SetSessionTTL(0) })
+	time.Sleep(5 * time.Millisecond)
+
+	if api.ExpireSession("c-idle") {
+		t.Fatal("sweeper expired a conversation owned by a human")
+	}
+	res := e.send("c-idle", "ainda estou aqui")
+	if res.Action != "forwarded" || res.Agent != "golpe_med" {
+		t.Errorf("human takeover lost after idle timeout: %+v", res)
+	}
+
+	// Once the ticket is closed the conversation expires normally
+	ticket := e.queue.List("")[0]
+	e.queue.Claim(ticket.ID, "ana")
+	e.queue.Close(ticket.ID, "ana")
+	e.store.SetMode("c-idle", core.ModeBot)
+	time.Sleep(5 * time.Millisecond)
+	if !api.ExpireSession("c-idle") {
+		t.Error("idle bot conversation not expired")
+	}
+}
+
+func TestOpenMEDClearsFormSlots(t *testing.T) {
+	llm := fake.New().
+		On(fake.Rule{System: golpePrompt, User: "RESULTADO DA TOOL", Response: fake.Plan(core.ActionPlan{
+			Action: "reply", Message: "Seu MED foi aberto.",
+			Slots: map[string]string{"valor": "250,00", "chave_pix": "golpista@gmail.com"},
+		})}).
+		On(fake.Rule{System: golpePrompt, Response: fake.Plan(core.ActionPlan{
+			Action: "call_api", Tool: "abrir_med", Confidence: 0.9,
+		})})
+	e := newEnv(t, llm, false)
+	e.store.SetAgent("c-med2", "golpe_med")
+
+	if res := e.send("c-med2", "paguei R$ 250,00 ontem para a chave golpista@gmail.com, B.O. 2026/12345"); res.Tool != "abrir_med" {
+		t.Fatalf("first case not opened: %+v", res)
+	}
+	for k, v := range e.store.GetAttrs("c-med2") {
+		if strings.HasPrefix(k, "slot.med.") {
+			t.Errorf("slot %s = %q kept after the case was opened", k, v)
+		}
+	}
+
+	// A second scam needs its own data: the old amount, key, date and B.O. are not reused
+	res := e.send("c-med2", "caí em outro golpe, mandei R$ 80 para outro@golpe.com")
+	if res.Action != "ask" || res.Tool != "" {
+		t.Errorf("second report opened a case with stale slots: %+v", res)
+	}
+	if v := e.store.GetAttrs("c-med2")["slot.med.valor"]; v != "80.00" {
+		t.Errorf("valor = %q, want the new amount", v)
+	}
+}
+
+func TestEndClearsFormSlots(t *testing.T) {
+	llm := fake.New().
+		On(fake.Rule{System: golpePrompt, Response: fake.Plan(core.ActionPlan{Action: "ask", NextQuestion: "Qual a data do Pix?", Confidence: 0.9})}).
+		On(fake.Rule{System: openFinancePrompt, Response: fake.Plan(core.ActionPlan{Action: "end", Message: "Até logo!", Confidence: 0.9})})
+	e := newEnv(t, llm, false)
+	e.store.SetAgent("c-end", "golpe_med")
+
+	e.send("c-end", "paguei R$ 250,00 para golpista@gmail.com")
+	if attrs := e.store.GetAttrs("c-end"); attrs["slot.med.valor"] == "" {
+		t.Fatalf("slots not collected: %v", attrs)
+	}
+
+	e.store.SetAgent("c-end", "open_finance")
+	if res := e.send("c-end", "deixa pra lá, obrigado"); res.Action != "end" {
+		t.Fatalf("unexpected response: %+v", res)
+	}
+	if attrs := e.store.GetAttrs("c-end"); len(attrs) != 0 {
+		t.Errorf("attrs kept after end: %v", attrs)
+	}
+}
+
+func TestEscalationPriorityFollowsAgentAndReason(t *testing.T) {
+	cases := []struct {
+		name   string
+		agent  string
+		prompt string
+		reason string
+		want   string
+	}{
+		{"security agent through the LLM", "golpe_med", golpePrompt, "cliente pediu humano", escalation.PriorityHigh},
+		{"fraud reason", "open_finance", openFinancePrompt, "cliente relata golpe com link falso", escalation.PriorityHigh},
+		{"regular case", "open_finance", openFinancePrompt, "cliente frustrado após várias tentativas", escalation.PriorityNormal},
+	}
+	for _, c := range cases {
+		llm := fake.New().On(fake.Rule{System: c.prompt, Response: fake.Plan(core.ActionPlan{
+			Action: "escalate", Message: "Vou te transferir.", HandoffReason: c.reason, Confidence: 0.9,
+		})})
+		e := newEnv(t, llm, false)
+		e.store.SetAgent("c-prio", c.agent)
+
+		if res := e.send("c-prio", "não consigo resolver"); res.Action != "escalate" {
+			t.Fatalf("%s: unexpected response: %+v", c.name, res)
+		}
+		tickets := e.queue.List("")
+		if len(tickets) != 1 || tickets[0].Priority != c.want {
+			t.Errorf("%s: tickets = %+v, want priority %s", c.name, tickets, c.want)
+		}
+	}
+}
+
+func TestEveryTurnIsSummarizedBeforeTrim(t *testing.T) {
+	llm := fake.New().On(fake.Rule{System: atendimentoPrompt, Response: reply("ok")})
+	e := newEnv(t, llm, false)
+	sum := fake.New().On(fake.Rule{Response: `{"summary": "resumo"}`})
+	// Trigger at the store limit: the bot reply fills the history and the next message would trim it
+	api.SetSummarizer(summary.New(sum), api.SummaryConfig{Trigger: 20, Keep: 4})
+	t.Cleanup(func() { api.SetSummarizer(nil, api.SummaryConfig{}) })
+
+	e.store.SetAgent("c-sum", core.DefaultAgent)
+	for i := 0; i < 18; i++ {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		e.store.Add("c-sum", core.ChatMessage{Role: role, Text: fmt.Sprintf("msg-%02d", i), Timestamp: time.Now()})
+	}
+
+	e.send("c-sum", "primeira")
+	e.send("c-sum", "segunda")
+
+	calls := sum.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("%d summaries, want 1", len(calls))
+	}
+	if !strings.Contains(calls[0].User, "Cliente: msg-00") {
+		t.Errorf("oldest turn dropped before being summarized:\n%s", calls[0].User)
+	}
+	if got := e.store.GetSummary("c-sum"); got != "resumo" {
+		t.Errorf("summary = %q", got)
+	}
+}
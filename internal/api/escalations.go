@@ -0,0 +1,170 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+	"github.com/bonettibruno/Jota_ProdOps/internal/escalation"
+)
+
+var escalations = escalation.NewQueue()
+
+// SetEscalationQueue replaces the queue that receives escalated conversations
+func SetEscalationQueue(q *escalation.Queue) {
+	escalations = q
+}
+
+// securityReasonRe flags escalation reasons about fraud, scams or account takeover
+var securityReasonRe = regexp.MustCompile(`(?i)golpe|fraud|invas|invadi|hacke|roub|furt|\bmed\b`)
+
+// enqueueEscalation hands the conversation over to the human desk. Security cases (pre-routed,
+// escalated by a security agent or with a fraud reason) go to the top of the queue.
+func enqueueEscalation(traceID, convID, agent, reason string, security bool) {
+	if spec, ok := core.LookupAgent(agent); ok && spec.Security {
+		security = true
+	}
+	if securityReasonRe.MatchString(reason) {
+		security = true
+	}
+
+	t := escalations.Enqueue(escalation.Request{
+		ConversationID: convID,
+		TraceID:        traceID,
+		Agent:          agent,
+		Reason:         reason,
+		Security:       security,
+		Transcript:     store.Get(convID),
+	})
+	log.Printf("trace=%s conv=%s event=escalation_queued ticket=%s priority=%s reason=\"%s\"",
+		traceID, convID, t.ID, t.Priority, t.Reason)
+}
+
+// operatorRequest is the body accepted by the claim, reply and close endpoints
+type operatorRequest struct {
+	Operator string `json:"operator"`
+	Message  string `json:"message"`
+}
+
+// EscalationsHandler lists the human queue, filtered by ?status= (default: open and claimed)
+func EscalationsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", escalation.StatusOpen, escalation.StatusClaimed, escalation.StatusClosed:
+	default:
+		http.Error(w, "invalid status", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(escalations.List(status))
+}
+
+// EscalationHandler returns a single ticket
+func EscalationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	t, err := escalations.Get(r.PathValue("id"))
+	writeTicket(w, t, err)
+}
+
+// EscalationActionHandler serves POST /escalations/{id}/{action} for claim, reply and close
+func EscalationActionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body operatorRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || strings.TrimSpace(body.Operator) == "" {
+		http.Error(w, "invalid request: operator is required", http.StatusBadRequest)
+		return
+	}
+
+	id := r.PathValue("id")
+	var t escalation.Ticket
+	var err error
+
+	switch r.PathValue("action") {
+	case "claim":
+		t, err = escalations.Claim(id, body.Operator)
+	case "reply":
+		if strings.TrimSpace(body.Message) == "" {
+			http.Error(w, "invalid request: message is required", http.StatusBadRequest)
+			return
+		}
+		t, err = escalations.Reply(id, body.Operator, body.Message)
+		if err == nil {
+			// The operator's answer becomes part of the conversation like any other turn
+			unlock := convLocks.Lock(t.ConversationID)
+			addMessage(r.Context(), t.TraceID, t.ConversationID, core.ChatMessage{Role: "human", Text: body.Message, Timestamp: time.Now()})
+			unlock()
+		}
+	case "close":
+		t, err = escalations.Close(id, body.Operator)
+		if err == nil {
+			// Closing the ticket hands the conversation back to the bot
+			unlock := convLocks.Lock(t.ConversationID)
+			if store.GetMode(t.ConversationID) == core.ModeHuman {
+				setMode(t.ConversationID, core.ModeBot, body.Operator)
+			}
+			unlock()
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if err == nil {
+		log.Printf("conv=%s event=escalation_%s ticket=%s operator=%s", t.ConversationID, r.PathValue("action"), t.ID, body.Operator)
+	}
+	writeTicket(w, t, err)
+}
+
+// OutboxHandler lets the channel poll operator messages not yet delivered to the customer
+func OutboxHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	msgs := escalations.DrainOutbox(r.PathValue("id"))
+	if msgs == nil {
+		msgs = []string{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"conversation_id":   r.PathValue("id"),
+		"operator_messages": msgs,
+	})
+}
+
+// writeTicket maps queue errors to HTTP status codes
+func writeTicket(w http.ResponseWriter, t escalation.Ticket, err error) {
+	switch {
+	case errors.Is(err, escalation.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, escalation.ErrConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(t)
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"context"
+	"log"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/experiment"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm"
+	"github.com/bonettibruno/Jota_ProdOps/internal/prompt"
+)
+
+var experiments *experiment.Set
+
+// SetExperiments installs the A/B experiments applied to agent runs (nil disables them)
+func SetExperiments(s *experiment.Set) {
+	experiments = s
+}
+
+// assignVariant returns the context carrying the agent's variant overrides and the "<experiment>/<variant>" key.
+// The first assignment is pinned in the conversation attributes so the variant stays sticky.
+func assignVariant(ctx context.Context, traceID, convID, agent string) (context.Context, string) {
+	exp, ok := experiments.For(agent)
+	if !ok {
+		return ctx, ""
+	}
+
+	v, pinned := exp.Variant(store.GetAttrs(convID)[exp.AttrKey()])
+	if !pinned {
+		v = exp.Bucket(convID)
+		store.SetAttr(convID, exp.AttrKey(), v.Name)
+		log.Printf("trace=%s conv=%s event=experiment_assigned experiment=%s variant=%s agent=%s",
+			traceID, convID, exp.Name, v.Name, agent)
+	}
+
+	ctx = prompt.WithTemplate(ctx, v.Template)
+	ctx = llm.WithModel(ctx, v.Model)
+	return ctx, exp.Name + "/" + v.Name
+}
@@ -4,15 +4,16 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
-	"github.com/bonettibruno/Jota_ProdOps/internal/agents/atendimento"
-	"github.com/bonettibruno/Jota_ProdOps/internal/agents/criacaoconta"
-	"github.com/bonettibruno/Jota_ProdOps/internal/agents/golpemed"
-	"github.com/bonettibruno/Jota_ProdOps/internal/agents/openfinance"
+	_ "github.com/bonettibruno/Jota_ProdOps/internal/agents" // registers every specialist agent
 	"github.com/bonettibruno/Jota_ProdOps/internal/core"
 	"github.com/bonettibruno/Jota_ProdOps/internal/llm"
+	"github.com/bonettibruno/Jota_ProdOps/internal/prerouter"
 	"github.com/bonettibruno/Jota_ProdOps/internal/rag"
+	"github.com/bonettibruno/Jota_ProdOps/internal/slots"
+	"github.com/bonettibruno/Jota_ProdOps/internal/tools"
 )
 
 type MessageRequest struct {
@@ -26,25 +27,65 @@ type MessageResponse struct {
 	Agent        string          `json:"agent"`
 	HistoryCount int             `json:"history_count"`
 	TraceID      string          `json:"trace_id"`
+	Tool         string          `json:"tool,omitempty"`
 	Citations    []core.Citation `json:"citations,omitempty"`
+	// PromptVersion identifies the system prompt template behind the reply (e.g. golpe_med@v1)
+	PromptVersion string `json:"prompt_version,omitempty"`
+	// Messages written by a human operator since the last response
+	OperatorMessages []string `json:"operator_messages,omitempty"`
 }
 
 var llmClient llm.Client
-var store = core.NewConversationStore(20)
+var store core.Store = core.NewConversationStore(20)
 var retriever *rag.Retriever
+var sessionTTL time.Duration
+var preRouter *prerouter.PreRouter
 
-// Agent mapping for the Orchestrator
-var brains = map[string]core.AgentBrain{
-	"open_finance":      &openfinance.Brain{},
-	"golpe_med":         &golpemed.Brain{},
-	"atendimento_geral": &atendimento.Brain{},
-	"criacao_conta":     &criacaoconta.Brain{},
-}
+// securityEscalationMsg is used when a security case goes to a human without an LLM answer
+const securityEscalationMsg = "Identifiquei que este é um caso de segurança e ele precisa de atenção imediata."
+
+// Messages of the same conversation are processed strictly one after another
+var convLocks = newKeyedMutex()
 
 func SetLLMClient(c llm.Client) {
 	llmClient = c
 }
 
+// SetStore replaces the conversation storage backend used by the handlers
+func SetStore(s core.Store) {
+	store = s
+}
+
+// SetPreRouter installs the deterministic rule layer evaluated before any brain (nil disables it)
+func SetPreRouter(p *prerouter.PreRouter) {
+	preRouter = p
+}
+
+// SetSessionTTL defines how long a conversation may stay idle before it is reset (0 disables)
+func SetSessionTTL(ttl time.Duration) {
+	sessionTTL = ttl
+}
+
+// ExpireSession resets an idle conversation for the background sweeper. It waits for any
+// in-flight turn of the conversation and never expires one that a human owns.
+func ExpireSession(convID string) bool {
+	unlock := convLocks.Lock(convID)
+	defer unlock()
+	return expireIfIdle(convID)
+}
+
+// expireIfIdle resets the conversation when it outlived the idle TTL, keeping it while an
+// escalation ticket is open or the bot is not in charge. Callers must hold the conversation lock.
+func expireIfIdle(convID string) bool {
+	if sessionTTL <= 0 {
+		return false
+	}
+	if _, open := escalations.Active(convID); open {
+		return false
+	}
+	return store.ExpireIfIdle(convID, sessionTTL)
+}
+
 func HealthHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write([]byte("ok"))
@@ -68,7 +109,7 @@ func init() {
 
 func MessagesHandler(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
-	m := core.GetMetrics()
+	m := core.Counters()
 
 	// 1. Traceability: Unique ID for request tracking
 	traceID := r.Header.Get("X-Trace-Id")
@@ -96,75 +137,294 @@ func MessagesHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("trace=%s conv=%s event=request_received msg=\"%s\"", traceID, req.ConversationID, req.Message)
 
+	// Serialize requests per conversation to avoid interleaved history and racing handoffs
+	unlock := convLocks.Lock(req.ConversationID)
+	defer unlock()
+
+	// Returning customers after the idle TTL start a fresh session with atendimento_geral
+	if last, ok := store.LastActivity(req.ConversationID); ok && expireIfIdle(req.ConversationID) {
+		log.Printf("trace=%s conv=%s event=session_reset reason=idle idle_for=%v", traceID, req.ConversationID, time.Since(last))
+	}
+
 	// 3. Persist user input in history
-	store.Add(req.ConversationID, core.ChatMessage{
+	addMessage(r.Context(), traceID, req.ConversationID, core.ChatMessage{
 		Role:      "user",
 		Text:      req.Message,
 		Timestamp: time.Now(),
 	})
 
+	// Human takeover: while the bot is not in charge no brain runs for this conversation
+	if mode := store.GetMode(req.ConversationID); mode != core.ModeBot {
+		silentResponse(w, traceID, req, mode, start)
+		return
+	}
+
 	var reply string
 	var currentAction string = "reply"
 	var finalAgent string
+	var escalationReason string
+	var promptVersion string
+	var variant string
+
+	var handoff *core.HandoffNote
+	var toolResult *core.ToolResult
+	var visited []string
+	var candidates []handoffCandidate
+
+	// Pre-router stage: deterministic rules for critical intents, independent of the LLM
+	preRouted := false
+	if preRouter != nil {
+		if dec, ok := preRouter.Evaluate(req.Message); ok {
+			preRouted = true
+			m.IncPreRoute(dec.Rules, dec.Escalate)
+			log.Printf("trace=%s conv=%s event=prerouter_match agent=%s escalate=%t score=%.2f rules=%s",
+				traceID, req.ConversationID, dec.Agent, dec.Escalate, dec.Score, strings.Join(dec.Rules, ","))
+
+			prev, assigned := store.GetAgent(req.ConversationID)
+			store.SetAgent(req.ConversationID, dec.Agent)
+			finalAgent = dec.Agent
+
+			if dec.Escalate {
+				currentAction = "escalate"
+				escalationReason = "regra de segurança: " + strings.Join(dec.Rules, ", ")
+				reply = finalizeResponse(core.ActionPlan{Action: "escalate", Message: securityEscalationMsg})
+			} else if assigned && prev != dec.Agent {
+				m.IncHandoff()
+				log.Printf("trace=%s conv=%s event=silent_handoff from=%s to=%s reason=\"prerouter\"",
+					traceID, req.ConversationID, prev, dec.Agent)
+				handoff = &core.HandoffNote{
+					From:    prev,
+					Reason:  "regra de segurança: " + strings.Join(dec.Rules, ", "),
+					Summary: core.SummarizeForHandoff(store.Get(req.ConversationID)),
+				}
+			}
+		}
+	}
 
-	// 4. Contextual RAG search
-	ragText := ""
-	if retriever != nil {
-		ragText = retriever.SearchAsText(req.Message, 3)
-		if ragText != "" {
-			log.Printf("trace=%s conv=%s event=rag_retrieval status=success", traceID, req.ConversationID)
+	// Router stage: triage new conversations (and topic shifts) straight to the right specialist
+	if routerCfg.Enabled && llmClient != nil && !preRouted {
+		history := store.Get(req.ConversationID)
+		if _, assigned := store.GetAgent(req.ConversationID); !assigned {
+			handoff = routeConversation(r.Context(), traceID, req.ConversationID, req.Message, history)
+		} else if topicShift(history, req.Message) {
+			log.Printf("trace=%s conv=%s event=topic_shift_detected", traceID, req.ConversationID)
+			handoff = routeConversation(r.Context(), traceID, req.ConversationID, req.Message, history)
 		}
 	}
 
-	// 5. Orchestration Loop (Policy Engine / Silent Handoff)
-	for i := 0; i < 3; i++ {
+	// 4. Orchestration Loop (Policy Engine / Silent Handoff)
+	for i := 0; reply == "" && i < maxHops; i++ {
 		history := store.Get(req.ConversationID)
 		log.Printf("trace=%s conv=%s event=debug_history messages_in_context=%d",
 			traceID, req.ConversationID, len(history))
 
 		agent, ok := store.GetAgent(req.ConversationID)
 		if !ok {
-			agent = "atendimento_geral"
+			agent = core.DefaultAgent
 			store.SetAgent(req.ConversationID, agent)
 		}
 		finalAgent = agent
+		if len(visited) == 0 || visited[len(visited)-1] != agent {
+			visited = append(visited, agent)
+		}
 
-		brain, exists := brains[agent]
+		spec, exists := core.LookupAgent(agent)
 		if !exists || llmClient == nil {
+			// Security cases flagged by the pre-router never fall back to a generic greeting
+			if preRouted {
+				currentAction = "escalate"
+				escalationReason = "caso de segurança sem agente disponível"
+				reply = finalizeResponse(core.ActionPlan{Action: "escalate", Message: securityEscalationMsg})
+				break
+			}
 			reply = "Olá! Eu sou a Aline do Jota. Como posso te ajudar hoje?"
 			break
 		}
 
+		// Contextual RAG search, restricted to the agent's knowledge base sections
+		ragText := ""
+		if retriever != nil {
+			ragText = retriever.SearchSectionsAsText(req.Message, 3, spec.KBSections)
+			if ragText != "" {
+				log.Printf("trace=%s conv=%s event=rag_retrieval status=success agent=%s", traceID, req.ConversationID, agent)
+			}
+		}
+
+		// Slot filling: extract and validate the data the agent's form collects
+		form, hasForm := slots.Lookup(spec.Form)
+		// Once the form's tool ran this turn its slots are spent: the agent only reports the result
+		if hasForm && toolResult != nil && toolResult.OK && toolResult.Tool == form.Tool {
+			hasForm = false
+		}
+		var slotState slots.State
+		slotPrompt := ""
+		if hasForm {
+			var problems []slots.Problem
+			slotState, problems = collectSlots(traceID, req.ConversationID, form, history, req.Message)
+			slotPrompt = form.PromptBlock(slotState, problems)
+		}
+
+		// A/B experiments: the agent's variant may override its prompt template and model
+		runCtx, runVariant := assignVariant(r.Context(), traceID, req.ConversationID, agent)
+		variant = runVariant
+
 		// Execute specialized Agent Brain
-		plan, err := brain.Run(r.Context(), llmClient, traceID, history, req.Message, ragText)
+		input := core.BrainInput{
+			TraceID:        traceID,
+			ConversationID: req.ConversationID,
+			History:        history,
+			UserMessage:    req.Message,
+			RAGContext:     ragText,
+			Handoff:        handoff,
+			ToolResult:     toolResult,
+			Slots:          slotPrompt,
+			Summary:        store.GetSummary(req.ConversationID),
+		}
+		plan, err := spec.Brain.Run(runCtx, llmClient, input)
 		if err != nil {
 			log.Printf("trace=%s conv=%s event=brain_error agent=%s err=%v", traceID, req.ConversationID, agent, err)
+			if preRouted {
+				currentAction = "escalate"
+				escalationReason = "falha do agente em caso de segurança"
+				reply = finalizeResponse(core.ActionPlan{Action: "escalate", Message: securityEscalationMsg})
+				break
+			}
 			reply = "Desculpe, tive um problema técnico momentâneo. Pode repetir, por favor?"
 			break
 		}
 
+		promptVersion = plan.PromptVersion
+
+		// Policy Engine: validate the plan against the agent's allowed actions and handoff targets
+		plan, violations, valid := core.EnforcePolicy(spec, plan)
+		for _, v := range violations {
+			m.IncPolicyViolation(agent, v.Rule)
+			log.Printf("trace=%s conv=%s event=policy_violation agent=%s rule=%s detail=\"%s\"",
+				traceID, req.ConversationID, agent, v.Rule, v.Detail)
+		}
+		if !valid {
+			m.IncRejectedPlan()
+			log.Printf("trace=%s conv=%s event=plan_rejected agent=%s action=%s", traceID, req.ConversationID, agent, plan.Action)
+			reply = "Desculpe, tive um problema técnico momentâneo. Pode repetir, por favor?"
+			break
+		}
+
+		// The shadow is compared before the form guard: it runs through the same policy, but the
+		// guard depends on this conversation's slots and would hide call_api disagreements
+		shadowPlan := plan
+
+		// The form's tool only runs once every slot holds a valid value
+		if hasForm {
+			mergePlanSlots(traceID, req.ConversationID, form, slotState, plan)
+
+			var complete bool
+			if plan, complete = guardFormTool(form, slotState, plan); !complete {
+				m.IncPolicyViolation(agent, "slots_incomplete")
+				log.Printf("trace=%s conv=%s event=policy_violation agent=%s rule=slots_incomplete detail=\"%s blocked, missing %d slot(s)\"",
+					traceID, req.ConversationID, agent, form.Tool, len(form.Missing(slotState)))
+			}
+		}
+
+		if variant != "" {
+			m.IncVariantPlan(variant, plan.Action)
+		}
+
+		// Shadow mode: a candidate brain gets the same input in background, never reaching the customer
+		if shadowRunner != nil {
+			shadowRunner.Submit(spec, input, shadowPlan)
+		}
+
 		// Handle agent transition (Handoff)
 		if plan.Action == "change_agent" {
-			m.IncHandoff()
 			newAgent := plan.ChangeAgent
-			if newAgent == "" || newAgent == "null" {
-				newAgent = "atendimento_geral"
+
+			// An agent pinned after a loop must not transfer again
+			if handoff != nil && handoff.Final {
+				log.Printf("trace=%s conv=%s event=handoff_loop_unresolved agent=%s to=%s", traceID, req.ConversationID, agent, newAgent)
+				break
+			}
+
+			candidates = append(candidates, handoffCandidate{From: agent, To: newAgent, Confidence: plan.Confidence})
+			note := &core.HandoffNote{
+				From:    agent,
+				Reason:  plan.HandoffReason,
+				Summary: core.SummarizeForHandoff(history),
+			}
+
+			// Ping-pong detection: the target already ran during this turn
+			if visitedAgent(visited, newAgent) {
+				m.IncHandoffLoop()
+				log.Printf("trace=%s conv=%s event=handoff_loop path=%s", traceID, req.ConversationID, handoffPath(visited, newAgent))
+
+				winner, ok := resolveHandoffLoop(candidates)
+				if !ok {
+					break
+				}
+				log.Printf("trace=%s conv=%s event=handoff_loop_resolved agent=%s", traceID, req.ConversationID, winner)
+				newAgent = winner
+				note.Final = true
 			}
 
-			log.Printf("trace=%s conv=%s event=silent_handoff from=%s to=%s reason=\"%s\"",
-				traceID, req.ConversationID, agent, newAgent, plan.HandoffReason)
+			if newAgent != agent {
+				m.IncHandoff()
+				log.Printf("trace=%s conv=%s event=silent_handoff from=%s to=%s reason=\"%s\"",
+					traceID, req.ConversationID, agent, newAgent, plan.HandoffReason)
+			}
 
 			store.SetAgent(req.ConversationID, newAgent)
+			handoff = note
 			continue // Re-process with the new specialist
 		}
 
+		// Tool execution: run the requested tool and call the same agent back with its result
+		if plan.Action == "call_api" && toolResult == nil {
+			res := runTool(r.Context(), traceID, req.ConversationID, agent, plan)
+			toolResult = &res
+			if hasForm && res.OK && plan.Tool == form.Tool {
+				clearSlots(traceID, req.ConversationID, form)
+			}
+			continue
+		}
+
+		// One tool per turn: a second call_api is never executed nor announced as if it ran
+		if plan.Action == "call_api" {
+			m.IncPolicyViolation(agent, "tool_repeat")
+			log.Printf("trace=%s conv=%s event=policy_violation agent=%s rule=tool_repeat detail=\"%s requested after %s\"",
+				traceID, req.ConversationID, agent, plan.Tool, toolResult.Tool)
+			if plan.Message == "" {
+				reply = "Desculpe, tive um problema técnico momentâneo. Pode repetir, por favor?"
+				break
+			}
+			plan.Action = "reply"
+		}
+
 		currentAction = plan.Action
+		if plan.Action == "escalate" {
+			escalationReason = plan.HandoffReason
+			if escalationReason == "" {
+				escalationReason = plan.Message
+			}
+		}
 		reply = finalizeResponse(plan)
+		if toolResult != nil && toolResult.OK && !tools.IsReadOnly(toolResult.Tool) {
+			reply += "\n\n[Protocolo de Segurança Ativado]"
+		}
 		break
 	}
 
-	// 6. Persist final assistant response
-	store.Add(req.ConversationID, core.ChatMessage{
+	// No agent produced an answer (unresolved loop or hop budget exhausted): hand over to a human
+	if reply == "" {
+		log.Printf("trace=%s conv=%s event=handoff_unresolved path=%s", traceID, req.ConversationID, strings.Join(visited, ">"))
+		currentAction = "escalate"
+		escalationReason = "handoff não resolvido: " + strings.Join(visited, ">")
+		reply = finalizeResponse(core.ActionPlan{
+			Action:  "escalate",
+			Message: "Não consegui identificar o especialista certo para o seu caso.",
+		})
+	}
+
+	// 5. Persist final assistant response
+	addMessage(r.Context(), traceID, req.ConversationID, core.ChatMessage{
 		Role:      "assistant",
 		Text:      reply,
 		Timestamp: time.Now(),
@@ -175,30 +435,39 @@ func MessagesHandler(w http.ResponseWriter, r *http.Request) {
 		m.IncEscalate()
 		log.Printf("trace=%s conv=%s event=HUMAN_INTERVENTION_REQUIRED level=CRITICAL agent=%s",
 			traceID, req.ConversationID, finalAgent)
+		enqueueEscalation(traceID, req.ConversationID, finalAgent, escalationReason, preRouted)
+		setMode(req.ConversationID, core.ModeHuman, "escalate")
 	}
 
-	// Handle automated banking tools (e.g., MED)
-	if currentAction == "call_api" {
-		log.Printf("trace=%s conv=%s event=EXECUTING_TOOL tool=abrir_med status=simulated", traceID, req.ConversationID)
-		reply = "Iniciei o protocolo MED (Mecanismo Especial de Devolução) para você. Vou acompanhar o processo e te aviso por aqui."
+	// The "end" action closes the session (history, agent and form slots) before the farewell is
+	// sent, so the next message, even one racing this response, starts from scratch
+	historyCount := len(store.Get(req.ConversationID))
+	if currentAction == "end" {
+		store.Reset(req.ConversationID)
+		log.Printf("trace=%s conv=%s event=session_closed agent=%s", traceID, req.ConversationID, finalAgent)
 	}
 
-	// 7. Send final response
+	// 6. Send final response
 	w.Header().Set("X-Trace-Id", traceID)
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(MessageResponse{
 		Reply:        reply,
 		Action:       currentAction,
 		Agent:        finalAgent,
-		HistoryCount: len(store.Get(req.ConversationID)),
+		HistoryCount: historyCount,
 		TraceID:      traceID,
+		Tool:         executedTool(toolResult),
+
+		PromptVersion: promptVersion,
+
+		OperatorMessages: escalations.DrainOutbox(req.ConversationID),
 	})
 
 	store.PrintAll()
 
 	m.IncRequest(finalAgent)
-	log.Printf("trace=%s conv=%s event=replied agent=%s action=%s latency=%v",
-		traceID, req.ConversationID, finalAgent, currentAction, time.Since(start))
+	log.Printf("trace=%s conv=%s event=replied agent=%s action=%s prompt=%s variant=%s latency=%v",
+		traceID, req.ConversationID, finalAgent, currentAction, promptVersion, variant, time.Since(start))
 }
 
 func finalizeResponse(plan core.ActionPlan) string {
@@ -206,11 +475,6 @@ func finalizeResponse(plan core.ActionPlan) string {
 
 	// Format specific action types
 	switch plan.Action {
-	case "call_api":
-		if res == "" {
-			res = "Entendido. Estou acionando nossos sistemas de segurança agora."
-		}
-		res += "\n\n[Protocolo de Segurança Ativado]"
 	case "ask", "collect_data":
 		if plan.NextQuestion != "" {
 			if res != "" {
@@ -220,6 +484,10 @@ func finalizeResponse(plan core.ActionPlan) string {
 		}
 	case "escalate":
 		res = "Sinto muito por isso. " + res + "\n\nEstou transferindo você agora para um especialista humano. Por favor, aguarde."
+	case "end":
+		if res == "" {
+			res = "Fico feliz em ter ajudado! Se precisar de algo mais, é só chamar."
+		}
 	}
 
 	if res == "" {
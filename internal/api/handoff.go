@@ -0,0 +1,48 @@
+package api
+
+import "strings"
+
+// maxHops bounds how many agents may run for a single customer message
+const maxHops = 4
+
+// loopMinConfidence is the minimum confidence a transfer needs to win a handoff loop
+const loopMinConfidence = 0.5
+
+// handoffCandidate records a transfer decision taken during the current turn
+type handoffCandidate struct {
+	From       string
+	To         string
+	Confidence float64
+}
+
+// resolveHandoffLoop deterministically picks the most confident transfer target among the
+// decisions that bounced the conversation. It returns false when none is confident enough,
+// meaning the case must be escalated to a human.
+func resolveHandoffLoop(candidates []handoffCandidate) (string, bool) {
+	best := -1
+	for i, c := range candidates {
+		// Strictly greater keeps the earliest decision on ties
+		if best < 0 || c.Confidence > candidates[best].Confidence {
+			best = i
+		}
+	}
+	if best < 0 || candidates[best].Confidence < loopMinConfidence {
+		return "", false
+	}
+	return candidates[best].To, true
+}
+
+// visitedAgent reports whether agent already ran during the current turn
+func visitedAgent(visited []string, agent string) bool {
+	for _, v := range visited {
+		if v == agent {
+			return true
+		}
+	}
+	return false
+}
+
+// handoffPath formats the agents visited in a turn for logging (e.g. open_finance>golpe_med)
+func handoffPath(visited []string, next string) string {
+	return strings.Join(append(append([]string(nil), visited...), next), ">")
+}
@@ -0,0 +1,45 @@
+package api
+
+import "sync"
+
+// keyedMutex serializes work per key while letting different keys run in parallel
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*refLock
+}
+
+// refLock is a mutex with a count of goroutines holding or waiting for it
+type refLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{locks: make(map[string]*refLock)}
+}
+
+// Lock blocks until key is free and returns the function that releases it
+func (k *keyedMutex) Lock(key string) func() {
+	k.mu.Lock()
+	l, ok := k.locks[key]
+	if !ok {
+		l = &refLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	l.mu.Lock()
+
+	return func() {
+		l.mu.Unlock()
+
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		// Drop the entry once nobody is waiting so the map does not grow forever
+		l.refs--
+		if l.refs == 0 {
+			delete(k.locks, key)
+		}
+	}
+}
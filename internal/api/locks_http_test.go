@@ -0,0 +1,67 @@
+package api_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm/fake"
+)
+
+// Concurrent HTTP messages go through the per-conversation lock (see locks.go)
+
+func TestConcurrentMessagesSameConversation(t *testing.T) {
+	llm := fake.New().On(fake.Rule{Response: reply("ok"), Delay: 20 * time.Millisecond})
+	e := newEnv(t, llm, false)
+
+	const n = 8
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			e.send("c-same", fmt.Sprintf("mensagem %d", i))
+		}(i)
+	}
+	wg.Wait()
+
+	if got := llm.MaxInFlight(); got != 1 {
+		t.Errorf("max concurrent llm calls = %d, want 1", got)
+	}
+
+	// Turns must never interleave: user, assistant, user, assistant...
+	history := e.store.Get("c-same")
+	if len(history) != 2*n {
+		t.Fatalf("history length = %d, want %d", len(history), 2*n)
+	}
+	for i, msg := range history {
+		want := "user"
+		if i%2 == 1 {
+			want = "assistant"
+		}
+		if msg.Role != want {
+			t.Fatalf("history[%d].Role = %q, want %q", i, msg.Role, want)
+		}
+	}
+}
+
+func TestConcurrentConversationsRunInParallel(t *testing.T) {
+	llm := fake.New().On(fake.Rule{Response: reply("ok"), Delay: 50 * time.Millisecond})
+	e := newEnv(t, llm, false)
+
+	const n = 4
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			e.send(fmt.Sprintf("c-par-%d", i), "oi")
+		}(i)
+	}
+	wg.Wait()
+
+	if got := llm.MaxInFlight(); got < 2 {
+		t.Errorf("max concurrent llm calls = %d, want parallel execution", got)
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeyedMutexSerializesSameKey(t *testing.T) {
+	k := newKeyedMutex()
+	var (
+		wg      sync.WaitGroup
+		running int32
+		overlap int32
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := k.Lock("c1")
+			defer unlock()
+			if atomic.AddInt32(&running, 1) > 1 {
+				atomic.StoreInt32(&overlap, 1)
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		}()
+	}
+	wg.Wait()
+
+	if overlap != 0 {
+		t.Error("two holders of the same key ran at once")
+	}
+	if n := len(k.locks); n != 0 {
+		t.Errorf("%d entries left after unlock", n)
+	}
+}
+
+func TestKeyedMutexDifferentKeysRunInParallel(t *testing.T) {
+	k := newKeyedMutex()
+	unlockA := k.Lock("a")
+
+	done := make(chan struct{})
+	go func() {
+		unlockB := k.Lock("b")
+		unlockB()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("key b blocked while key a was held")
+	}
+	unlockA()
+
+	if n := len(k.locks); n != 0 {
+		t.Errorf("%d entries left after unlock", n)
+	}
+}
+
+func TestKeyedMutexWaiterKeepsEntry(t *testing.T) {
+	k := newKeyedMutex()
+	unlock := k.Lock("c1")
+
+	acquired := make(chan func())
+	go func() { acquired <- k.Lock("c1") }()
+
+	// Wait until the second goroutine is registered as a waiter
+	for {
+		k.mu.Lock()
+		refs := k.locks["c1"].refs
+		k.mu.Unlock()
+		if refs == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-acquired:
+		t.Fatal("second Lock acquired a held key")
+	default:
+	}
+
+	unlock()
+	second := <-acquired
+	k.mu.Lock()
+	_, ok := k.locks["c1"]
+	k.mu.Unlock()
+	if !ok {
+		t.Fatal("entry dropped while still held")
+	}
+	second()
+	if n := len(k.locks); n != 0 {
+		t.Errorf("%d entries left after unlock", n)
+	}
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/med"
+)
+
+var medCases *med.Store
+
+// SetMEDStore wires the MED case store used by the case endpoints
+func SetMEDStore(s *med.Store) {
+	medCases = s
+}
+
+// MEDCasesHandler lists MED cases, filtered by ?conversation_id= and ?status=
+func MEDCasesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if medCases == nil {
+		http.Error(w, "med cases not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var status med.Status
+	if s := r.URL.Query().Get("status"); s != "" {
+		st, err := med.ParseStatus(s)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		status = st
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(medCases.List(r.URL.Query().Get("conversation_id"), status))
+}
+
+// MEDCaseHandler returns a case (GET) or moves it to a new status (PATCH {"status", "note"})
+func MEDCaseHandler(w http.ResponseWriter, r *http.Request) {
+	if medCases == nil {
+		http.Error(w, "med cases not configured", http.StatusServiceUnavailable)
+		return
+	}
+	id := r.PathValue("id")
+
+	var c med.Case
+	var err error
+
+	switch r.Method {
+	case http.MethodGet:
+		c, err = medCases.Get(id)
+	case http.MethodPatch:
+		var body struct {
+			Status string `json:"status"`
+			Note   string `json:"note"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		status, perr := med.ParseStatus(body.Status)
+		if perr != nil {
+			http.Error(w, perr.Error(), http.StatusBadRequest)
+			return
+		}
+		c, err = medCases.UpdateStatus(id, status, body.Note)
+		if err == nil {
+			log.Printf("conv=%s event=med_status_changed case=%s status=%s", c.ConversationID, c.ID, c.Status)
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch {
+	case errors.Is(err, med.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, med.ErrInvalidTransition):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(c)
+}
@@ -0,0 +1,19 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/prompt"
+)
+
+// PromptsHandler lists the active system prompt templates with their versions and sources
+func PromptsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(prompt.Templates())
+}
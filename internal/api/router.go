@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"log"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+	"github.com/bonettibruno/Jota_ProdOps/internal/rag"
+)
+
+// RouterConfig controls the optional LLM triage step that runs before the agent brains
+type RouterConfig struct {
+	Enabled bool
+	// Threshold is the minimum RouterDecision.Confidence required to assign the agent
+	Threshold float64
+}
+
+var routerCfg = RouterConfig{Threshold: 0.7}
+
+// SetRouterConfig enables or tunes the router stage
+func SetRouterConfig(cfg RouterConfig) {
+	routerCfg = cfg
+}
+
+// routeConversation asks the LLM router for the best agent and assigns it when confident enough.
+// It returns a handoff note when the conversation was moved away from a previously assigned agent.
+func routeConversation(ctx context.Context, traceID, convID, message string, history []core.ChatMessage) *core.HandoffNote {
+	m := core.Counters()
+
+	dec, err := llmClient.RouteAgent(ctx, traceID, message, routerHistory(history))
+	if err != nil {
+		m.IncRouterError()
+		log.Printf("trace=%s conv=%s event=router_error err=%v", traceID, convID, err)
+		return nil
+	}
+
+	_, registered := core.LookupAgent(dec.Agent)
+	applied := registered && dec.Confidence >= routerCfg.Threshold
+	m.IncRouterDecision(dec.Agent, applied)
+	log.Printf("trace=%s conv=%s event=router_decision agent=%s confidence=%.2f threshold=%.2f applied=%t reason=\"%s\"",
+		traceID, convID, dec.Agent, dec.Confidence, routerCfg.Threshold, applied, dec.Reason)
+
+	if !applied {
+		return nil
+	}
+
+	prev, assigned := store.GetAgent(convID)
+	store.SetAgent(convID, dec.Agent)
+	if !assigned || prev == dec.Agent {
+		return nil
+	}
+
+	m.IncHandoff()
+	log.Printf("trace=%s conv=%s event=silent_handoff from=%s to=%s reason=\"router: %s\"",
+		traceID, convID, prev, dec.Agent, dec.Reason)
+	return &core.HandoffNote{
+		From:    prev,
+		Reason:  dec.Reason,
+		Summary: core.SummarizeForHandoff(history),
+	}
+}
+
+// routerHistory formats the latest turns (excluding the current message) for the router prompt
+func routerHistory(history []core.ChatMessage) []string {
+	const maxTurns = 6
+
+	if n := len(history); n > 0 && history[n-1].Role == "user" {
+		history = history[:n-1]
+	}
+	if len(history) > maxTurns {
+		history = history[len(history)-maxTurns:]
+	}
+
+	out := make([]string, 0, len(history))
+	for _, msg := range history {
+		role := "Cliente"
+		switch msg.Role {
+		case "assistant":
+			role = "Assistente"
+		case "human":
+			role = "Atendente humano"
+		}
+		out = append(out, role+": "+msg.Text)
+	}
+	return out
+}
+
+// topicShift reports whether the message shares no keywords with the customer's previous messages
+func topicShift(history []core.ChatMessage, message string) bool {
+	const minTokens = 3
+	const lookback = 2
+
+	current := rag.Tokenize(message)
+	if len(current) < minTokens {
+		return false
+	}
+
+	previous := make(map[string]bool)
+	seen := 0
+	// Skip the current message, already appended to history
+	for i := len(history) - 2; i >= 0 && seen < lookback; i-- {
+		if history[i].Role != "user" {
+			continue
+		}
+		seen++
+		for _, tok := range rag.Tokenize(history[i].Text) {
+			previous[tok] = true
+		}
+	}
+	if seen == 0 {
+		return false
+	}
+
+	for _, tok := range current {
+		if previous[tok] {
+			return false
+		}
+	}
+	return true
+}
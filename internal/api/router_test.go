@@ -0,0 +1,146 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm/fake"
+)
+
+// withRouter installs a fake router and a fresh store for one test
+func withRouter(t *testing.T, dec llm.RouterDecision, err error) *core.ConversationStore {
+	t.Helper()
+	prevClient, prevStore, prevCfg := llmClient, store, routerCfg
+	t.Cleanup(func() { llmClient, store, routerCfg = prevClient, prevStore, prevCfg })
+
+	s := core.NewConversationStore(20)
+	SetLLMClient(fake.New().Route(dec, err))
+	SetStore(s)
+	SetRouterConfig(RouterConfig{Enabled: true, Threshold: 0.7})
+	return s
+}
+
+func TestRouteConversationThreshold(t *testing.T) {
+	cases := []struct {
+		name     string
+		dec      llm.RouterDecision
+		err      error
+		wantSets bool
+	}{
+		{"confident", llm.RouterDecision{Agent: "open_finance", Confidence: 0.9}, nil, true},
+		{"at threshold", llm.RouterDecision{Agent: "open_finance", Confidence: 0.7}, nil, true},
+		{"low confidence", llm.RouterDecision{Agent: "open_finance", Confidence: 0.69}, nil, false},
+		{"unknown agent", llm.RouterDecision{Agent: "emprestimos_x", Confidence: 0.99}, nil, false},
+		{"router error", llm.RouterDecision{}, errors.New("timeout"), false},
+	}
+	for _, c := range cases {
+		s := withRouter(t, c.dec, c.err)
+		history := []core.ChatMessage{{Role: "user", Text: "oi"}}
+
+		// A new conversation is assigned without a handoff note
+		if note := routeConversation(context.Background(), "t", "c1", "oi", history); note != nil {
+			t.Errorf("%s: handoff note for a new conversation: %+v", c.name, note)
+		}
+		agent, assigned := s.GetAgent("c1")
+		if assigned != c.wantSets || (c.wantSets && agent != c.dec.Agent) {
+			t.Errorf("%s: agent = %q, assigned = %t; want assigned = %t", c.name, agent, assigned, c.wantSets)
+		}
+	}
+}
+
+func TestRouteConversationHandoffNote(t *testing.T) {
+	s := withRouter(t, llm.RouterDecision{Agent: "open_finance", Confidence: 0.9, Reason: "open finance"}, nil)
+	history := []core.ChatMessage{
+		{Role: "user", Text: "quero abrir conta"},
+		{Role: "assistant", Text: "Claro!"},
+		{Role: "user", Text: "meu link do open finance não abre"},
+	}
+
+	s.SetAgent("c1", "criacao_conta")
+	note := routeConversation(context.Background(), "t", "c1", history[2].Text, history)
+	if note == nil || note.From != "criacao_conta" || note.Reason != "open finance" {
+		t.Fatalf("note = %+v", note)
+	}
+	if agent, _ := s.GetAgent("c1"); agent != "open_finance" {
+		t.Errorf("agent = %q", agent)
+	}
+
+	// Re-routing to the same agent is not a handoff
+	if note := routeConversation(context.Background(), "t", "c1", history[2].Text, history); note != nil {
+		t.Errorf("handoff note without a change of agent: %+v", note)
+	}
+}
+
+func TestTopicShift(t *testing.T) {
+	user := func(text string) core.ChatMessage { return core.ChatMessage{Role: "user", Text: text} }
+	bot := core.ChatMessage{Role: "assistant", Text: "Entendi, pode detalhar?"}
+
+	cases := []struct {
+		name    string
+		history []core.ChatMessage
+		message string
+		want    bool
+	}{
+		{"new subject", []core.ChatMessage{user("meu cartão foi bloqueado ontem"), bot}, "como conecto open finance banco", true},
+		{"same subject", []core.ChatMessage{user("meu cartão foi bloqueado ontem"), bot}, "cartão continua sem funcionar", false},
+		{"too short", []core.ChatMessage{user("meu cartão foi bloqueado ontem"), bot}, "open finance", false},
+		{"first message", nil, "como conecto open finance banco", false},
+		{"only bot turns", []core.ChatMessage{bot}, "como conecto open finance banco", false},
+		{
+			"outside lookback",
+			[]core.ChatMessage{user("open finance banco"), bot, user("cartão bloqueado"), bot, user("fatura atrasada"), bot},
+			"como conecto open finance banco", true,
+		},
+	}
+	for _, c := range cases {
+		// MessagesHandler appends the current message before the router stage runs
+		history := append(c.history, user(c.message))
+		if got := topicShift(history, c.message); got != c.want {
+			t.Errorf("%s: topicShift = %t, want %t", c.name, got, c.want)
+		}
+	}
+}
+
+func TestTopicShiftReroutesAssignedConversation(t *testing.T) {
+	s := withRouter(t, llm.RouterDecision{Agent: "open_finance", Confidence: 0.9}, nil)
+	SetLLMClient(fake.New().
+		Route(llm.RouterDecision{Agent: "open_finance", Confidence: 0.9}, nil).
+		On(fake.Rule{Response: fake.Plan(core.ActionPlan{Action: "reply", Message: "ok", Confidence: 0.9})}))
+	s.SetAgent("c1", "criacao_conta")
+	s.Add("c1", core.ChatMessage{Role: "user", Text: "meu cadastro está travado na selfie"})
+	s.Add("c1", core.ChatMessage{Role: "assistant", Text: "Tente com boa iluminação."})
+
+	// Same subject: the router is not consulted and the agent stays
+	post(t, "c1", "cadastro continua travado na selfie")
+	if agent, _ := s.GetAgent("c1"); agent != "criacao_conta" {
+		t.Fatalf("agent = %q after a same-subject message", agent)
+	}
+
+	post(t, "c1", "como conecto outro banco pelo open finance")
+	if agent, _ := s.GetAgent("c1"); agent != "open_finance" {
+		t.Errorf("agent = %q, want open_finance after the topic shift", agent)
+	}
+}
+
+// post runs one customer message through MessagesHandler
+func post(t *testing.T, convID, msg string) MessageResponse {
+	t.Helper()
+	body, _ := json.Marshal(MessageRequest{ConversationID: convID, Message: msg})
+	rec := httptest.NewRecorder()
+	MessagesHandler(rec, httptest.NewRequest(http.MethodPost, "/messages", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /messages: status %d: %s", rec.Code, rec.Body)
+	}
+	var out MessageResponse
+	if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/shadow"
+)
+
+var shadowRunner *shadow.Runner
+
+// SetShadowRunner enables shadow runs of candidate brains (nil disables them)
+func SetShadowRunner(r *shadow.Runner) {
+	shadowRunner = r
+}
+
+// ShadowReportHandler reports, per agent, how often the shadow candidate agrees with production
+func ShadowReportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if shadowRunner == nil {
+		http.Error(w, "shadow mode not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"agents":  shadowRunner.Report(),
+		"dropped": shadowRunner.Dropped(),
+	})
+}
+
+// ShadowRecordsHandler lists recent production/shadow plan pairs, filtered by ?agent=, ?diffs=true and ?limit=
+func ShadowRecordsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if shadowRunner == nil {
+		http.Error(w, "shadow mode not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	q := r.URL.Query()
+	limit := 50
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(shadowRunner.Records(q.Get("agent"), q.Get("diffs") == "true", limit))
+}
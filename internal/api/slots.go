@@ -0,0 +1,83 @@
+package api
+
+import (
+	"log"
+	"time"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+	"github.com/bonettibruno/Jota_ProdOps/internal/slots"
+)
+
+// collectSlots extracts form data from the customer's messages and persists the valid values.
+// The first time a form is active (e.g. right after a handoff) the whole history is scanned,
+// except the messages that were already submitted to the form's tool.
+func collectSlots(traceID, convID string, f *slots.Form, history []core.ChatMessage, message string) (slots.State, []slots.Problem) {
+	attrs := store.GetAttrs(convID)
+	st := f.Load(attrs)
+
+	msgs := []string{message}
+	if len(st) == 0 {
+		since, _ := time.Parse(time.RFC3339Nano, attrs[f.SubmittedKey()])
+		msgs = msgs[:0]
+		for _, h := range history {
+			if h.Role == "user" && (since.IsZero() || h.Timestamp.After(since)) {
+				msgs = append(msgs, h.Text)
+			}
+		}
+	}
+
+	updates, problems := f.ExtractFrom(msgs, time.Now())
+	saveSlots(traceID, convID, f, st, updates)
+	return st, problems
+}
+
+// mergePlanSlots validates the slots reported by the model and persists the valid ones
+func mergePlanSlots(traceID, convID string, f *slots.Form, st slots.State, plan core.ActionPlan) {
+	updates, problems := f.Merge(plan.Slots, time.Now())
+	for _, p := range problems {
+		log.Printf("trace=%s conv=%s event=slot_rejected form=%s slot=%s reason=\"%s\"", traceID, convID, f.Name, p.Slot, p.Message)
+	}
+	saveSlots(traceID, convID, f, st, updates)
+}
+
+// saveSlots applies updates to the in-turn state and the conversation attributes
+func saveSlots(traceID, convID string, f *slots.Form, st, updates slots.State) {
+	for name, v := range updates {
+		if st[name] == v {
+			continue
+		}
+		st[name] = v
+		store.SetAttr(convID, f.AttrKey(name), v)
+		log.Printf("trace=%s conv=%s event=slot_filled form=%s slot=%s", traceID, convID, f.Name, name)
+	}
+}
+
+// clearSlots empties the form once its tool ran, so a new report in the same conversation
+// starts from scratch instead of reusing the previous values
+func clearSlots(traceID, convID string, f *slots.Form) {
+	for _, s := range f.Slots {
+		store.SetAttr(convID, f.AttrKey(s.Name), "")
+	}
+	store.SetAttr(convID, f.SubmittedKey(), time.Now().Format(time.RFC3339Nano))
+	log.Printf("trace=%s conv=%s event=slots_cleared form=%s", traceID, convID, f.Name)
+}
+
+// guardFormTool blocks the form's tool until every slot is valid and feeds it the collected values
+func guardFormTool(f *slots.Form, st slots.State, plan core.ActionPlan) (core.ActionPlan, bool) {
+	if plan.Action != "call_api" || plan.Tool != f.Tool {
+		return plan, true
+	}
+
+	if !f.Complete(st) {
+		plan.Action = "ask"
+		plan.Tool = ""
+		plan.ToolArgs = nil
+		plan.Message = "Para seguir com a solicitação, ainda preciso de algumas informações."
+		plan.NextQuestion = f.NextQuestion(st)
+		return plan, false
+	}
+
+	// Collected slots are the source of truth for the tool arguments
+	plan.ToolArgs = f.ToolArgs(st)
+	return plan, true
+}
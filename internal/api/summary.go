@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	"log"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+	"github.com/bonettibruno/Jota_ProdOps/internal/summary"
+)
+
+// SummaryConfig controls rolling summarization of long conversations
+type SummaryConfig struct {
+	// Trigger is the history size that starts a compaction (must stay below the store limit)
+	Trigger int
+	// Keep is how many recent messages remain verbatim after compaction
+	Keep int
+}
+
+var summarizer *summary.Summarizer
+var summaryCfg SummaryConfig
+
+// SetSummarizer enables rolling summarization (nil disables it)
+func SetSummarizer(s *summary.Summarizer, cfg SummaryConfig) {
+	summarizer = s
+	summaryCfg = cfg
+}
+
+// addMessage appends a message to the history, summarizing first when the append could make the
+// store drop its oldest turn. Every writer (customer, bot and operator) goes through it, so no
+// turn leaves the history without being summarized. Callers must hold the conversation lock.
+func addMessage(ctx context.Context, traceID, convID string, msg core.ChatMessage) {
+	compactHistory(ctx, traceID, convID)
+	store.Add(convID, msg)
+}
+
+// compactHistory condenses the oldest turns into the rolling summary once the history is about to overflow.
+// Callers must hold the conversation lock.
+func compactHistory(ctx context.Context, traceID, convID string) {
+	if summarizer == nil || summaryCfg.Trigger <= 0 {
+		return
+	}
+	history := store.Get(convID)
+	if len(history) < summaryCfg.Trigger || len(history) <= summaryCfg.Keep {
+		return
+	}
+
+	old := history[:len(history)-summaryCfg.Keep]
+	sum := summarizer.Summarize(ctx, traceID, store.GetSummary(convID), old)
+	store.Compact(convID, sum, summaryCfg.Keep)
+
+	log.Printf("trace=%s conv=%s event=history_summarized summarized=%d kept=%d", traceID, convID, len(old), summaryCfg.Keep)
+}
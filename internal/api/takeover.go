@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+)
+
+// silentResponse answers a message without running any brain while a human owns (or paused) the conversation
+func silentResponse(w http.ResponseWriter, traceID string, req MessageRequest, mode core.Mode, start time.Time) {
+	action := "paused"
+	if mode == core.ModeHuman {
+		action = "forwarded"
+		forwardToHuman(traceID, req.ConversationID, req.Message)
+	}
+	core.Counters().IncBotSilenced(mode)
+
+	agent, _ := store.GetAgent(req.ConversationID)
+
+	w.Header().Set("X-Trace-Id", traceID)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(MessageResponse{
+		Action:       action,
+		Agent:        agent,
+		HistoryCount: len(store.Get(req.ConversationID)),
+		TraceID:      traceID,
+
+		OperatorMessages: escalations.DrainOutbox(req.ConversationID),
+	})
+
+	log.Printf("trace=%s conv=%s event=replied agent=%s action=%s mode=%s latency=%v",
+		traceID, req.ConversationID, agent, action, mode, time.Since(start))
+}
+
+// forwardToHuman appends the customer message to the active ticket, reopening one if it was lost
+func forwardToHuman(traceID, convID, message string) {
+	msg := core.ChatMessage{Role: "user", Text: message, Timestamp: time.Now()}
+	if t, ok := escalations.Forward(convID, msg); ok {
+		log.Printf("trace=%s conv=%s event=forwarded_to_human ticket=%s", traceID, convID, t.ID)
+		return
+	}
+
+	agent, _ := store.GetAgent(convID)
+	enqueueEscalation(traceID, convID, agent, "mensagem recebida em modo humano sem ticket ativo", false)
+}
+
+// setMode changes who answers the conversation and logs the transition
+func setMode(convID string, mode core.Mode, by string) {
+	prev := store.GetMode(convID)
+	if prev == mode {
+		return
+	}
+	store.SetMode(convID, mode)
+	log.Printf("conv=%s event=mode_changed from=%s to=%s by=%s", convID, prev, mode, by)
+}
+
+// ModeHandler reads (GET) or changes (PUT {"mode", "operator"}) who answers a conversation
+func ModeHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var body struct {
+			Mode     string `json:"mode"`
+			Operator string `json:"operator"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		mode, err := core.ParseMode(body.Mode)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		by := body.Operator
+		if by == "" {
+			by = "api"
+		}
+
+		unlock := convLocks.Lock(id)
+		// A manual takeover still needs a ticket so the desk sees the conversation
+		if mode == core.ModeHuman {
+			if _, ok := escalations.Active(id); !ok {
+				agent, _ := store.GetAgent(id)
+				enqueueEscalation(newTraceID(), id, agent, "atendimento assumido manualmente por "+by, false)
+			}
+		}
+		setMode(id, mode, by)
+		unlock()
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"conversation_id": id,
+		"mode":            store.GetMode(id),
+	})
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+	"github.com/bonettibruno/Jota_ProdOps/internal/tools"
+)
+
+// runTool executes the tool requested by a call_api plan and records its outcome
+func runTool(ctx context.Context, traceID, convID, agent string, plan core.ActionPlan) core.ToolResult {
+	start := time.Now()
+	res := tools.Run(ctx, plan.Tool, tools.Call{
+		TraceID:        traceID,
+		ConversationID: convID,
+		Args:           plan.ToolArgs,
+	})
+
+	core.Counters().IncToolCall(plan.Tool, res.OK)
+	log.Printf("trace=%s conv=%s event=tool_executed agent=%s tool=%s ok=%t latency=%v error=\"%s\"",
+		traceID, convID, agent, plan.Tool, res.OK, time.Since(start), res.Error)
+	return res
+}
+
+// executedTool returns the name of the tool run during the turn, if any
+func executedTool(res *core.ToolResult) string {
+	if res == nil {
+		return ""
+	}
+	return res.Tool
+}
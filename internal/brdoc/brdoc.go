@@ -0,0 +1,114 @@
+// Package brdoc validates and normalizes Brazilian identifiers: CPF, CNPJ and Pix keys.
+// Error messages are in Portuguese so agents can relay them to the customer as-is.
+package brdoc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError explains precisely why an identifier is invalid
+type ValidationError struct {
+	Kind   string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	// "chave Pix" and "chave aleatória" are feminine in Portuguese
+	if strings.HasPrefix(e.Kind, "chave") {
+		return e.Kind + " inválida: " + e.Reason
+	}
+	return e.Kind + " inválido: " + e.Reason
+}
+
+func invalid(kind, format string, args ...any) error {
+	return &ValidationError{Kind: kind, Reason: fmt.Sprintf(format, args...)}
+}
+
+// OnlyDigits strips every non-digit character (dots, dashes, slashes, spaces)
+func OnlyDigits(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// ValidateCPF checks length, repeated digits and both check digits
+func ValidateCPF(s string) error {
+	d := OnlyDigits(s)
+	if len(d) != 11 {
+		return invalid("CPF", "deve ter 11 dígitos, mas tem %d", len(d))
+	}
+	if allSame(d) {
+		return invalid("CPF", "todos os dígitos são iguais")
+	}
+
+	dv1 := checkDigit(d[:9], []int{10, 9, 8, 7, 6, 5, 4, 3, 2})
+	if int(d[9]-'0') != dv1 {
+		return invalid("CPF", "o primeiro dígito verificador está incorreto")
+	}
+	dv2 := checkDigit(d[:10], []int{11, 10, 9, 8, 7, 6, 5, 4, 3, 2})
+	if int(d[10]-'0') != dv2 {
+		return invalid("CPF", "o segundo dígito verificador está incorreto")
+	}
+	return nil
+}
+
+// ValidateCNPJ checks length, repeated digits and both check digits
+func ValidateCNPJ(s string) error {
+	d := OnlyDigits(s)
+	if len(d) != 14 {
+		return invalid("CNPJ", "deve ter 14 dígitos, mas tem %d", len(d))
+	}
+	if allSame(d) {
+		return invalid("CNPJ", "todos os dígitos são iguais")
+	}
+
+	dv1 := checkDigit(d[:12], []int{5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2})
+	if int(d[12]-'0') != dv1 {
+		return invalid("CNPJ", "o primeiro dígito verificador está incorreto")
+	}
+	dv2 := checkDigit(d[:13], []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2})
+	if int(d[13]-'0') != dv2 {
+		return invalid("CNPJ", "o segundo dígito verificador está incorreto")
+	}
+	return nil
+}
+
+// FormatCPF renders a CPF as 000.000.000-00 (input is returned unchanged if it is not 11 digits)
+func FormatCPF(s string) string {
+	d := OnlyDigits(s)
+	if len(d) != 11 {
+		return s
+	}
+	return d[:3] + "." + d[3:6] + "." + d[6:9] + "-" + d[9:]
+}
+
+// FormatCNPJ renders a CNPJ as 00.000.000/0000-00 (input is returned unchanged if it is not 14 digits)
+func FormatCNPJ(s string) string {
+	d := OnlyDigits(s)
+	if len(d) != 14 {
+		return s
+	}
+	return d[:2] + "." + d[2:5] + "." + d[5:8] + "/" + d[8:12] + "-" + d[12:]
+}
+
+// checkDigit computes a mod-11 check digit with the given weights
+func checkDigit(digits string, weights []int) int {
+	sum := 0
+	for i, w := range weights {
+		sum += int(digits[i]-'0') * w
+	}
+	r := sum % 11
+	if r < 2 {
+		return 0
+	}
+	return 11 - r
+}
+
+func allSame(d string) bool {
+	return strings.Count(d, d[:1]) == len(d)
+}
@@ -0,0 +1,60 @@
+package brdoc
+
+import "testing"
+
+func TestValidateCPF(t *testing.T) {
+	cases := []struct {
+		in     string
+		reason string
+	}{
+		{in: "529.982.247-25"},
+		{in: "52998224725"},
+		{in: "529.982.247-35", reason: "o primeiro dígito verificador está incorreto"},
+		{in: "529.982.247-24", reason: "o segundo dígito verificador está incorreto"},
+		{in: "111.111.111-11", reason: "todos os dígitos são iguais"},
+		{in: "123.456.789", reason: "deve ter 11 dígitos, mas tem 9"},
+	}
+	for _, c := range cases {
+		checkReason(t, "ValidateCPF", c.in, ValidateCPF(c.in), c.reason)
+	}
+}
+
+func TestValidateCNPJ(t *testing.T) {
+	cases := []struct {
+		in     string
+		reason string
+	}{
+		{in: "11.222.333/0001-81"},
+		{in: "11222333000181"},
+		{in: "11.222.333/0001-91", reason: "o primeiro dígito verificador está incorreto"},
+		{in: "11.222.333/0001-80", reason: "o segundo dígito verificador está incorreto"},
+		{in: "00.000.000/0000-00", reason: "todos os dígitos são iguais"},
+		{in: "11.222.333/0001", reason: "deve ter 14 dígitos, mas tem 12"},
+	}
+	for _, c := range cases {
+		checkReason(t, "ValidateCNPJ", c.in, ValidateCNPJ(c.in), c.reason)
+	}
+}
+
+func checkReason(t *testing.T, fn, in string, err error, reason string) {
+	t.Helper()
+	if reason == "" {
+		if err != nil {
+			t.Errorf("%s(%q) = %v, want valid", fn, in, err)
+		}
+		return
+	}
+	ve, ok := err.(*ValidationError)
+	if !ok || ve.Reason != reason {
+		t.Errorf("%s(%q) = %v, want %q", fn, in, err, reason)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	if got := FormatCPF("52998224725"); got != "529.982.247-25" {
+		t.Errorf("FormatCPF = %q", got)
+	}
+	if got := FormatCNPJ("11222333000181"); got != "11.222.333/0001-81" {
+		t.Errorf("FormatCNPJ = %q", got)
+	}
+}
@@ -0,0 +1,114 @@
+package brdoc
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Pix key types as defined by the DICT (Diretório de Identificadores de Contas Transacionais)
+const (
+	PixCPF   = "cpf"
+	PixCNPJ  = "cnpj"
+	PixPhone = "telefone"
+	PixEmail = "email"
+	PixEVP   = "aleatoria"
+)
+
+// PixKey is a detected and normalized Pix key
+type PixKey struct {
+	Type  string
+	Value string
+}
+
+var (
+	emailRe     = regexp.MustCompile(`(?i)^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}$`)
+	evpRe       = regexp.MustCompile(`(?i)^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	phoneRe     = regexp.MustCompile(`^\+?[\d\s().\-]+$`)
+	cpfFormatRe = regexp.MustCompile(`^\d{3}\.\d{3}\.\d{3}-\d{2}$`)
+)
+
+// ValidateEmail checks the e-mail format and the 77 character DICT limit
+func ValidateEmail(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) > 77 {
+		return "", invalid("e-mail", "tem mais de 77 caracteres")
+	}
+	if !emailRe.MatchString(s) {
+		return "", invalid("e-mail", "formato não reconhecido")
+	}
+	return s, nil
+}
+
+// ValidateEVP checks a random (EVP) key, which is a UUID
+func ValidateEVP(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if !evpRe.MatchString(s) {
+		return "", invalid("chave aleatória", "deve ter o formato xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx")
+	}
+	return s, nil
+}
+
+// ValidatePhone checks a Brazilian mobile number and normalizes it to +55DDNNNNNNNNN
+func ValidatePhone(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if !phoneRe.MatchString(s) {
+		return "", invalid("telefone", "contém caracteres inválidos")
+	}
+
+	d := OnlyDigits(s)
+	if strings.HasPrefix(d, "55") && len(d) == 13 {
+		d = d[2:]
+	}
+	if len(d) != 11 {
+		return "", invalid("telefone", "deve ter DDD + 9 dígitos, mas tem %d dígitos", len(d))
+	}
+	if d[0] == '0' || d[1] == '0' {
+		return "", invalid("telefone", "o DDD %s não existe", d[:2])
+	}
+	if d[2] != '9' {
+		return "", invalid("telefone", "número de celular deve começar com 9 após o DDD")
+	}
+	return "+55" + d, nil
+}
+
+// ParsePixKey detects the key type and validates it. Eleven bare digits are read as a
+// CPF when the check digits match, otherwise as a mobile number (the DICT requires +55
+// for phones, but customers rarely type it).
+func ParsePixKey(s string) (PixKey, error) {
+	s = strings.TrimSpace(s)
+
+	switch {
+	case strings.Contains(s, "@"):
+		v, err := ValidateEmail(s)
+		return PixKey{Type: PixEmail, Value: v}, err
+	case evpRe.MatchString(s):
+		v, err := ValidateEVP(s)
+		return PixKey{Type: PixEVP, Value: v}, err
+	case strings.HasPrefix(s, "+"):
+		v, err := ValidatePhone(s)
+		return PixKey{Type: PixPhone, Value: v}, err
+	}
+
+	d := OnlyDigits(s)
+	switch len(d) {
+	case 14:
+		if err := ValidateCNPJ(d); err != nil {
+			return PixKey{}, err
+		}
+		return PixKey{Type: PixCNPJ, Value: d}, nil
+	case 11:
+		if ValidateCPF(d) == nil {
+			return PixKey{Type: PixCPF, Value: d}, nil
+		}
+		// Written as 000.000.000-00 it can only be a CPF with wrong check digits
+		if cpfFormatRe.MatchString(s) {
+			return PixKey{}, ValidateCPF(d)
+		}
+		v, err := ValidatePhone(s)
+		return PixKey{Type: PixPhone, Value: v}, err
+	case 13:
+		v, err := ValidatePhone(s)
+		return PixKey{Type: PixPhone, Value: v}, err
+	}
+	return PixKey{}, invalid("chave Pix", "formato não reconhecido (use CPF, CNPJ, telefone, e-mail ou chave aleatória)")
+}
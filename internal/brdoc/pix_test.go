@@ -0,0 +1,43 @@
+package brdoc
+
+import "testing"
+
+func TestParsePixKey(t *testing.T) {
+	cases := []struct {
+		in      string
+		typ     string
+		value   string
+		wantErr bool
+	}{
+		{in: "Golpista@Gmail.com", typ: PixEmail, value: "golpista@gmail.com"},
+		{in: "golpista@", wantErr: true},
+		{in: "123e4567-e89b-12d3-a456-426614174000", typ: PixEVP, value: "123e4567-e89b-12d3-a456-426614174000"},
+		{in: "+55 (11) 98765-4321", typ: PixPhone, value: "+5511987654321"},
+		{in: "11987654321", typ: PixPhone, value: "+5511987654321"},
+		{in: "(11) 3765-4321", wantErr: true},
+		{in: "529.982.247-25", typ: PixCPF, value: "52998224725"},
+		{in: "52998224725", typ: PixCPF, value: "52998224725"},
+		{in: "529.982.247-24", wantErr: true},
+		{in: "11.222.333/0001-81", typ: PixCNPJ, value: "11222333000181"},
+		{in: "11.222.333/0001-80", wantErr: true},
+		{in: "abc", wantErr: true},
+	}
+	for _, c := range cases {
+		k, err := ParsePixKey(c.in)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("ParsePixKey(%q) = %+v, want error", c.in, k)
+			}
+			continue
+		}
+		if err != nil || k.Type != c.typ || k.Value != c.value {
+			t.Errorf("ParsePixKey(%q) = %+v, %v; want %s %s", c.in, k, err, c.typ, c.value)
+		}
+	}
+}
+
+func TestValidatePhoneDDD(t *testing.T) {
+	if _, err := ValidatePhone("01987654321"); err == nil {
+		t.Error("DDD 01 accepted")
+	}
+}
@@ -0,0 +1,91 @@
+package brdoc
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Finding is a CPF or CNPJ spotted in free text with its validation outcome
+type Finding struct {
+	Kind    string
+	Raw     string
+	Value   string
+	Problem string
+}
+
+// Valid reports whether the identifier passed validation
+func (f Finding) Valid() bool {
+	return f.Problem == ""
+}
+
+var (
+	cnpjScanRe = regexp.MustCompile(`\b\d{2}\.?\d{3}\.?\d{3}/?\d{4}-?\d{2}\b`)
+	cpfScanRe  = regexp.MustCompile(`\b\d{3}\.?\d{3}\.?\d{3}-?\d{2}\b`)
+	// hintNumberRe captures the number token following "CPF"/"CNPJ" (e.g. "CPF: 123.456.789")
+	hintNumberRe = regexp.MustCompile(`(?i)\b(cpf|cnpj)\b[^\d\n]{0,15}?(\d[\d./-]*\d)`)
+)
+
+// Scan finds CPF and CNPJ numbers in a customer message and validates each one
+func Scan(text string) []Finding {
+	var out []Finding
+
+	for _, raw := range cnpjScanRe.FindAllString(text, -1) {
+		f := Finding{Kind: "CNPJ", Raw: raw, Value: FormatCNPJ(raw)}
+		if err := ValidateCNPJ(raw); err != nil {
+			f.Problem = err.(*ValidationError).Reason
+		}
+		out = append(out, f)
+	}
+	text = cnpjScanRe.ReplaceAllString(text, " ")
+
+	for _, raw := range cpfScanRe.FindAllString(text, -1) {
+		f := Finding{Kind: "CPF", Raw: raw, Value: FormatCPF(raw)}
+		if err := ValidateCPF(raw); err != nil {
+			f.Problem = err.(*ValidationError).Reason
+		}
+		out = append(out, f)
+	}
+
+	// "CNPJ 12.345.678/0001" style partial numbers still deserve a precise answer. Only the
+	// number right after the keyword is read, never digits scattered across the message.
+	if len(out) == 0 {
+		for _, m := range hintNumberRe.FindAllStringSubmatch(text, -1) {
+			d := OnlyDigits(m[2])
+			if len(d) < 9 {
+				continue
+			}
+			kind := strings.ToUpper(m[1])
+			f := Finding{Kind: kind, Raw: m[2], Value: d}
+			var err error
+			if kind == "CPF" {
+				err = ValidateCPF(d)
+			} else {
+				err = ValidateCNPJ(d)
+			}
+			if err != nil {
+				f.Problem = err.(*ValidationError).Reason
+			}
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// PromptBlock renders findings for injection in an agent prompt
+func PromptBlock(findings []Finding) string {
+	if len(findings) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("VALIDAÇÃO AUTOMÁTICA DE DOCUMENTOS (confiável, não recalcule):\n")
+	for _, f := range findings {
+		if f.Valid() {
+			sb.WriteString("- " + f.Kind + " " + f.Value + ": válido\n")
+			continue
+		}
+		sb.WriteString("- " + f.Kind + " " + f.Value + ": INVÁLIDO (" + f.Problem + ")\n")
+	}
+	sb.WriteString("Se houver documento inválido, explique exatamente o problema ao cliente e peça para conferir o número.\n\n")
+	return sb.String()
+}
@@ -0,0 +1,53 @@
+package brdoc
+
+import "testing"
+
+func TestScan(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		want []Finding
+	}{
+		{
+			name: "valid cpf",
+			text: "meu cpf é 529.982.247-25",
+			want: []Finding{{Kind: "CPF", Raw: "529.982.247-25", Value: "529.982.247-25"}},
+		},
+		{
+			name: "cnpj with wrong check digit",
+			text: "CNPJ 11.222.333/0001-80",
+			want: []Finding{{Kind: "CNPJ", Raw: "11.222.333/0001-80", Value: "11.222.333/0001-80", Problem: "o segundo dígito verificador está incorreto"}},
+		},
+		{
+			name: "partial cpf after hint",
+			text: "CPF: 123.456.789",
+			want: []Finding{{Kind: "CPF", Raw: "123.456.789", Value: "123456789", Problem: "deve ter 11 dígitos, mas tem 9"}},
+		},
+		{
+			// Digits from unrelated tokens must not be glued into a made-up number
+			name: "no false concatenation",
+			text: "CPF 123.456.789 nao passa, ja tentei 2x",
+			want: []Finding{{Kind: "CPF", Raw: "123.456.789", Value: "123456789", Problem: "deve ter 11 dígitos, mas tem 9"}},
+		},
+		{
+			name: "hint without number",
+			text: "esqueci meu cpf, tenho 2 contas e 3 cartões de 2019",
+		},
+		{
+			name: "no document",
+			text: "quero abrir uma conta",
+		},
+	}
+	for _, c := range cases {
+		got := Scan(c.text)
+		if len(got) != len(c.want) {
+			t.Errorf("%s: Scan(%q) = %+v, want %+v", c.name, c.text, got, c.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("%s: finding %d = %+v, want %+v", c.name, i, got[i], c.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileStore is a ConversationStore that persists its state to a JSON file,
+// so conversations and assigned agents survive process restarts.
+// Every write serializes the whole store, so its cost grows with the number of
+// conversations; SetFlushInterval batches the changes of a window into one write.
+type FileStore struct {
+	*ConversationStore
+
+	path   string
+	saveMu sync.Mutex
+
+	flushMu    sync.Mutex
+	flushEvery time.Duration
+	pending    *time.Timer
+}
+
+// NewFileStore loads the store from path (if it exists) and saves it on every change until
+// SetFlushInterval enables batching
+func NewFileStore(path string, limit int) (*FileStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create store dir: %w", err)
+	}
+
+	fs := &FileStore{
+		ConversationStore: NewConversationStore(limit),
+		path:              path,
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read store file: %w", err)
+	}
+
+	var snap storeSnapshot
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return nil, fmt.Errorf("decode store file: %w", err)
+	}
+	fs.restore(snap)
+
+	return fs, nil
+}
+
+// Add appends a message and persists the new state
+func (s *FileStore) Add(convID string, msg ChatMessage) {
+	s.ConversationStore.Add(convID, msg)
+	s.changed()
+}
+
+// SetAgent updates the active agent and persists the new state
+func (s *FileStore) SetAgent(convID, agent string) {
+	s.ConversationStore.SetAgent(convID, agent)
+	s.changed()
+}
+
+// SetAttr updates a conversation attribute and persists the new state
+func (s *FileStore) SetAttr(convID, key, value string) {
+	s.ConversationStore.SetAttr(convID, key, value)
+	s.changed()
+}
+
+// Compact replaces the summary, trims the history and persists the new state
+func (s *FileStore) Compact(convID, summary string, keep int) {
+	s.ConversationStore.Compact(convID, summary, keep)
+	s.changed()
+}
+
+// SetMode changes who answers the conversation and persists the new state
+func (s *FileStore) SetMode(convID string, mode Mode) {
+	s.ConversationStore.SetMode(convID, mode)
+	s.changed()
+}
+
+// Reset closes the session and persists the new state
+func (s *FileStore) Reset(convID string) {
+	s.ConversationStore.Reset(convID)
+	s.changed()
+}
+
+// ExpireIfIdle resets an idle conversation and persists the new state
+func (s *FileStore) ExpireIfIdle(convID string, ttl time.Duration) bool {
+	expired := s.ConversationStore.ExpireIfIdle(convID, ttl)
+	if expired {
+		s.changed()
+	}
+	return expired
+}
+
+// SetFlushInterval batches writes: changes made within d are persisted together (0 saves every change)
+func (s *FileStore) SetFlushInterval(d time.Duration) {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+	s.flushEvery = d
+}
+
+// Flush writes pending changes to disk right away
+func (s *FileStore) Flush() {
+	s.flushMu.Lock()
+	if s.pending != nil {
+		s.pending.Stop()
+		s.pending = nil
+	}
+	s.flushMu.Unlock()
+
+	s.save()
+}
+
+// changed persists a mutation now, or schedules a flush when batching is enabled
+func (s *FileStore) changed() {
+	s.flushMu.Lock()
+	every := s.flushEvery
+	if every > 0 && s.pending == nil {
+		s.pending = time.AfterFunc(every, s.Flush)
+	}
+	s.flushMu.Unlock()
+
+	if every <= 0 {
+		s.save()
+	}
+}
+
+// save writes the current snapshot atomically (temp file + rename)
+func (s *FileStore) save() {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	b, err := json.Marshal(s.snapshot())
+	if err != nil {
+		log.Printf("event=store_save_failed path=%s error=%v", s.path, err)
+		return
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		log.Printf("event=store_save_failed path=%s error=%v", s.path, err)
+		return
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		log.Printf("event=store_save_failed path=%s error=%v", s.path, err)
+	}
+}
@@ -0,0 +1,94 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStoreSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store", "conversations.json")
+	s, err := NewFileStore(path, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Add("c1", ChatMessage{Role: "user", Text: "caí num golpe", Timestamp: time.Now()})
+	s.Add("c1", ChatMessage{Role: "assistant", Text: "Qual o valor?", Timestamp: time.Now()})
+	s.SetAgent("c1", "golpe_med")
+	s.SetAttr("c1", "slot.med.valor", "250.00")
+	s.SetMode("c1", ModeHuman)
+	s.Add("c2", ChatMessage{Role: "user", Text: "oi", Timestamp: time.Now()})
+	s.Compact("c2", "cliente cumprimentou", 1)
+
+	r, err := NewFileStore(path, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := r.Get("c1"); len(h) != 2 || h[0].Text != "caí num golpe" || h[1].Role != "assistant" {
+		t.Errorf("history = %+v", h)
+	}
+	if agent, ok := r.GetAgent("c1"); !ok || agent != "golpe_med" {
+		t.Errorf("agent = %q, %t", agent, ok)
+	}
+	if v := r.GetAttrs("c1")["slot.med.valor"]; v != "250.00" {
+		t.Errorf("attr = %q", v)
+	}
+	if m := r.GetMode("c1"); m != ModeHuman {
+		t.Errorf("mode = %q", m)
+	}
+	if sum := r.GetSummary("c2"); sum != "cliente cumprimentou" {
+		t.Errorf("summary = %q", sum)
+	}
+	if _, ok := r.LastActivity("c2"); !ok {
+		t.Error("last activity lost")
+	}
+}
+
+func TestFileStoreBatchesWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conversations.json")
+	s, err := NewFileStore(path, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetFlushInterval(time.Hour)
+
+	s.Add("c1", ChatMessage{Role: "user", Text: "oi"})
+	s.SetAgent("c1", "golpe_med")
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("batched change written before the flush: %v", err)
+	}
+
+	s.Flush()
+	r, err := NewFileStore(path, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if agent, _ := r.GetAgent("c1"); agent != "golpe_med" || len(r.Get("c1")) != 1 {
+		t.Errorf("flushed state not reloaded: agent=%q history=%v", agent, r.Get("c1"))
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Error("temp file left behind")
+	}
+}
+
+func TestFileStoreFlushesAfterInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conversations.json")
+	s, err := NewFileStore(path, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetFlushInterval(10 * time.Millisecond)
+	s.Add("c1", ChatMessage{Role: "user", Text: "oi"})
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("batched change never written")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"strings"
+)
+
+// HandoffNote carries context from the agent that transferred the conversation
+type HandoffNote struct {
+	From    string
+	Reason  string
+	Summary string
+	// Final forbids the receiving agent from transferring again (set after a handoff loop)
+	Final bool
+}
+
+// PromptBlock renders the note for injection in the receiving agent's prompt
+func (n *HandoffNote) PromptBlock() string {
+	if n == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("NOTA DE TRANSFERÊNCIA (recebida de \"" + n.From + "\"):\n")
+	if n.Reason != "" {
+		sb.WriteString("Motivo: " + n.Reason + "\n")
+	}
+	if n.Summary != "" {
+		sb.WriteString("O que o cliente já disse: " + n.Summary + "\n")
+	}
+	sb.WriteString("Não pergunte novamente o que o cliente já informou.\n")
+	if n.Final {
+		sb.WriteString("Não transfira novamente: resolva o atendimento ou use action=\"escalate\".\n")
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// SummarizeForHandoff condenses the latest customer messages into a short deterministic summary
+func SummarizeForHandoff(history []ChatMessage) string {
+	const maxMessages = 3
+	const maxLen = 300
+
+	var parts []string
+	for i := len(history) - 1; i >= 0 && len(parts) < maxMessages; i-- {
+		if history[i].Role == "user" {
+			parts = append([]string{strings.TrimSpace(history[i].Text)}, parts...)
+		}
+	}
+
+	summary := strings.Join(parts, " | ")
+	if r := []rune(summary); len(r) > maxLen {
+		summary = string(r[:maxLen-3]) + "..."
+	}
+	return summary
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"sync"
+	"time"
 )
 
 // ConversationStore manages in-memory chat history and agent state per conversation
@@ -9,7 +10,12 @@ type ConversationStore struct {
 	mu     sync.Mutex
 	items  map[string][]ChatMessage
 	agents map[string]string
+	seen   map[string]time.Time
+	attrs  map[string]map[string]string
+	modes  map[string]Mode
 	limit  int
+
+	summaries map[string]string
 }
 
 // NewConversationStore initializes a new store with a history limit per user
@@ -17,7 +23,12 @@ func NewConversationStore(limit int) *ConversationStore {
 	return &ConversationStore{
 		items:  make(map[string][]ChatMessage),
 		agents: make(map[string]string),
+		seen:   make(map[string]time.Time),
+		attrs:  make(map[string]map[string]string),
+		modes:  make(map[string]Mode),
 		limit:  limit,
+
+		summaries: make(map[string]string),
 	}
 }
 
@@ -34,6 +45,7 @@ func (s *ConversationStore) Add(convID string, msg ChatMessage) {
 	}
 
 	s.items[convID] = h
+	s.seen[convID] = time.Now()
 }
 
 // Get retrieves a thread-safe copy of the conversation history
@@ -66,6 +78,213 @@ func (s *ConversationStore) SetAgent(convID, agent string) {
 	s.agents[convID] = agent
 }
 
+// GetSummary returns the rolling summary of the turns already dropped from the history
+func (s *ConversationStore) GetSummary(convID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.summaries[convID]
+}
+
+// Compact replaces the summary and keeps only the last keep messages of the history
+func (s *ConversationStore) Compact(convID, summary string, keep int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if h := s.items[convID]; len(h) > keep {
+		s.items[convID] = append([]ChatMessage(nil), h[len(h)-keep:]...)
+	}
+	if summary == "" {
+		delete(s.summaries, convID)
+		return
+	}
+	s.summaries[convID] = summary
+}
+
+// GetAttrs returns a copy of the key/value attributes attached to the conversation
+func (s *ConversationStore) GetAttrs(convID string) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]string, len(s.attrs[convID]))
+	for k, v := range s.attrs[convID] {
+		out[k] = v
+	}
+	return out
+}
+
+// SetAttr attaches a key/value attribute to the conversation (an empty value removes it)
+func (s *ConversationStore) SetAttr(convID, key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if value == "" {
+		delete(s.attrs[convID], key)
+		return
+	}
+	if s.attrs[convID] == nil {
+		s.attrs[convID] = make(map[string]string)
+	}
+	s.attrs[convID][key] = value
+}
+
+// GetMode returns who is answering the conversation (ModeBot when never set)
+func (s *ConversationStore) GetMode(convID string) Mode {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if m, ok := s.modes[convID]; ok {
+		return m
+	}
+	return ModeBot
+}
+
+// SetMode hands the conversation to the bot, a human operator or pauses it
+func (s *ConversationStore) SetMode(convID string, mode Mode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if mode == ModeBot {
+		delete(s.modes, convID)
+		return
+	}
+	s.modes[convID] = mode
+}
+
+// LastActivity returns when the conversation last received a message
+func (s *ConversationStore) LastActivity(convID string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.seen[convID]
+	return t, ok
+}
+
+// Reset closes the session, dropping its history and assigned agent
+func (s *ConversationStore) Reset(convID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.items, convID)
+	delete(s.agents, convID)
+	delete(s.seen, convID)
+	delete(s.attrs, convID)
+	delete(s.modes, convID)
+	delete(s.summaries, convID)
+}
+
+// IdleConversations lists the conversations idle for longer than ttl. Conversations owned by a
+// human or paused are left out: a takeover must never be undone by the idle timeout.
+func (s *ConversationStore) IdleConversations(ttl time.Duration) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var idle []string
+	for id := range s.seen {
+		if s.idleLocked(id, ttl) {
+			idle = append(idle, id)
+		}
+	}
+	return idle
+}
+
+// ExpireIfIdle re-checks the conversation and resets it only if it is still idle in bot mode,
+// so a message that arrived after IdleConversations keeps its session
+func (s *ConversationStore) ExpireIfIdle(convID string, ttl time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.idleLocked(convID, ttl) {
+		return false
+	}
+	delete(s.items, convID)
+	delete(s.agents, convID)
+	delete(s.seen, convID)
+	delete(s.attrs, convID)
+	delete(s.summaries, convID)
+	return true
+}
+
+// idleLocked reports whether convID is idle for longer than ttl and answered by the bot; callers must hold s.mu
+func (s *ConversationStore) idleLocked(convID string, ttl time.Duration) bool {
+	t, ok := s.seen[convID]
+	if !ok || time.Since(t) <= ttl {
+		return false
+	}
+	_, taken := s.modes[convID]
+	return !taken
+}
+
+// snapshot returns a deep copy of the store state for persistence
+func (s *ConversationStore) snapshot() storeSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := storeSnapshot{
+		Items:  make(map[string][]ChatMessage, len(s.items)),
+		Agents: make(map[string]string, len(s.agents)),
+		Seen:   make(map[string]time.Time, len(s.seen)),
+		Attrs:  make(map[string]map[string]string, len(s.attrs)),
+		Modes:  make(map[string]Mode, len(s.modes)),
+
+		Summaries: make(map[string]string, len(s.summaries)),
+	}
+	for id, h := range s.items {
+		snap.Items[id] = append([]ChatMessage(nil), h...)
+	}
+	for id, agent := range s.agents {
+		snap.Agents[id] = agent
+	}
+	for id, t := range s.seen {
+		snap.Seen[id] = t
+	}
+	for id, attrs := range s.attrs {
+		cp := make(map[string]string, len(attrs))
+		for k, v := range attrs {
+			cp[k] = v
+		}
+		snap.Attrs[id] = cp
+	}
+	for id, m := range s.modes {
+		snap.Modes[id] = m
+	}
+	for id, sum := range s.summaries {
+		snap.Summaries[id] = sum
+	}
+	return snap
+}
+
+// restore replaces the store state with a previously persisted snapshot
+func (s *ConversationStore) restore(snap storeSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items = make(map[string][]ChatMessage, len(snap.Items))
+	s.agents = make(map[string]string, len(snap.Agents))
+	s.seen = make(map[string]time.Time, len(snap.Seen))
+	s.attrs = make(map[string]map[string]string, len(snap.Attrs))
+	s.modes = make(map[string]Mode, len(snap.Modes))
+	s.summaries = make(map[string]string, len(snap.Summaries))
+	for id, h := range snap.Items {
+		s.items[id] = h
+	}
+	for id, agent := range snap.Agents {
+		s.agents[id] = agent
+	}
+	for id, t := range snap.Seen {
+		s.seen[id] = t
+	}
+	for id, attrs := range snap.Attrs {
+		s.attrs[id] = attrs
+	}
+	for id, m := range snap.Modes {
+		s.modes[id] = m
+	}
+	for id, sum := range snap.Summaries {
+		s.summaries[id] = sum
+	}
+}
+
 // PrintAll dumps all active conversations to the console for debugging
 func (s *ConversationStore) PrintAll() {
 	s.mu.Lock()
@@ -81,8 +300,11 @@ func (s *ConversationStore) PrintAll() {
 		println("ID:", id, "| AGENT:", agent, "| MSGS:", len(messages))
 		for _, m := range messages {
 			role := "U"
-			if m.Role == "assistant" {
+			switch m.Role {
+			case "assistant":
 				role = "A"
+			case "human":
+				role = "H"
 			}
 
 			text := m.Text
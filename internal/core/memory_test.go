@@ -0,0 +1,56 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpireIfIdleKeepsTakenOverConversations(t *testing.T) {
+	s := NewConversationStore(20)
+	for _, id := range []string{"bot", "human", "paused"} {
+		s.Add(id, ChatMessage{Role: "user", Text: "oi", Timestamp: time.Now()})
+		s.SetAgent(id, "golpe_med")
+	}
+	s.SetMode("human", ModeHuman)
+	s.SetMode("paused", ModePaused)
+	time.Sleep(5 * time.Millisecond)
+
+	idle := s.IdleConversations(time.Millisecond)
+	if len(idle) != 1 || idle[0] != "bot" {
+		t.Fatalf("idle = %v, want [bot]", idle)
+	}
+
+	for _, id := range []string{"human", "paused"} {
+		if s.ExpireIfIdle(id, time.Millisecond) {
+			t.Errorf("%s conversation expired", id)
+		}
+		if len(s.Get(id)) != 1 || s.GetMode(id) == ModeBot {
+			t.Errorf("%s conversation lost its state", id)
+		}
+	}
+
+	if !s.ExpireIfIdle("bot", time.Millisecond) {
+		t.Fatal("idle bot conversation not expired")
+	}
+	if _, ok := s.GetAgent("bot"); ok || len(s.Get("bot")) != 0 {
+		t.Error("expired conversation kept its state")
+	}
+}
+
+func TestExpireIfIdleRechecksActivity(t *testing.T) {
+	s := NewConversationStore(20)
+	s.Add("c", ChatMessage{Role: "user", Text: "oi", Timestamp: time.Now()})
+	time.Sleep(5 * time.Millisecond)
+
+	if idle := s.IdleConversations(time.Millisecond); len(idle) != 1 {
+		t.Fatalf("idle = %v", idle)
+	}
+	// A message arriving between listing and expiring keeps the session
+	s.Add("c", ChatMessage{Role: "user", Text: "voltei", Timestamp: time.Now()})
+	if s.ExpireIfIdle("c", time.Second) {
+		t.Error("active conversation expired")
+	}
+	if len(s.Get("c")) != 2 {
+		t.Error("history lost")
+	}
+}
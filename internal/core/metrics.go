@@ -7,19 +7,109 @@ type Metrics struct {
 	mu              sync.Mutex
 	TotalRequests   int            `json:"total_requests"`
 	TotalHandoffs   int            `json:"total_handoffs"`
+	HandoffLoops    int            `json:"handoff_loops"`
 	TotalEscalates  int            `json:"total_escalates"`
 	RequestsByAgent map[string]int `json:"requests_by_agent"`
+
+	PolicyViolations  int            `json:"policy_violations"`
+	ViolationsByRule  map[string]int `json:"violations_by_rule"`
+	ViolationsByAgent map[string]int `json:"violations_by_agent"`
+	RejectedPlans     int            `json:"rejected_plans"`
+
+	RouterCalls     int            `json:"router_calls"`
+	RouterApplied   int            `json:"router_applied"`
+	RouterErrors    int            `json:"router_errors"`
+	RouterDecisions map[string]int `json:"router_decisions"`
+
+	PreRouted            int            `json:"prerouted"`
+	PreRouterEscalations int            `json:"prerouter_escalations"`
+	PreRouterRules       map[string]int `json:"prerouter_rules"`
+
+	ToolCalls  map[string]int `json:"tool_calls"`
+	ToolErrors map[string]int `json:"tool_errors"`
+
+	BotSilenced map[string]int `json:"bot_silenced"`
+
+	// Experiments is keyed by "<experiment>/<variant>"
+	Experiments map[string]*VariantStats `json:"experiments"`
+}
+
+// VariantStats summarizes the plans produced by one experiment variant
+type VariantStats struct {
+	Runs           int            `json:"runs"`
+	Escalations    int            `json:"escalations"`
+	Handoffs       int            `json:"handoffs"`
+	EscalationRate float64        `json:"escalation_rate"`
+	HandoffRate    float64        `json:"handoff_rate"`
+	Actions        map[string]int `json:"actions"`
 }
 
 var globalMetrics = &Metrics{
-	RequestsByAgent: make(map[string]int),
+	RequestsByAgent:   make(map[string]int),
+	ViolationsByRule:  make(map[string]int),
+	ViolationsByAgent: make(map[string]int),
+	RouterDecisions:   make(map[string]int),
+	PreRouterRules:    make(map[string]int),
+	ToolCalls:         make(map[string]int),
+	ToolErrors:        make(map[string]int),
+	BotSilenced:       make(map[string]int),
+	Experiments:       make(map[string]*VariantStats),
 }
 
-// GetMetrics returns the singleton instance of operational metrics
-func GetMetrics() *Metrics {
+// Counters returns the live metrics instance that request handling increments
+func Counters() *Metrics {
 	return globalMetrics
 }
 
+// GetMetrics returns a consistent copy of the metrics, safe to read while requests are counted
+func GetMetrics() *Metrics {
+	return globalMetrics.Snapshot()
+}
+
+// Snapshot deep-copies the counters under the lock
+func (m *Metrics) Snapshot() *Metrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	experiments := make(map[string]*VariantStats, len(m.Experiments))
+	for k, st := range m.Experiments {
+		cp := *st
+		cp.Actions = copyCounts(st.Actions)
+		experiments[k] = &cp
+	}
+	return &Metrics{
+		TotalRequests:        m.TotalRequests,
+		TotalHandoffs:        m.TotalHandoffs,
+		HandoffLoops:         m.HandoffLoops,
+		TotalEscalates:       m.TotalEscalates,
+		RequestsByAgent:      copyCounts(m.RequestsByAgent),
+		PolicyViolations:     m.PolicyViolations,
+		ViolationsByRule:     copyCounts(m.ViolationsByRule),
+		ViolationsByAgent:    copyCounts(m.ViolationsByAgent),
+		RejectedPlans:        m.RejectedPlans,
+		RouterCalls:          m.RouterCalls,
+		RouterApplied:        m.RouterApplied,
+		RouterErrors:         m.RouterErrors,
+		RouterDecisions:      copyCounts(m.RouterDecisions),
+		PreRouted:            m.PreRouted,
+		PreRouterEscalations: m.PreRouterEscalations,
+		PreRouterRules:       copyCounts(m.PreRouterRules),
+		ToolCalls:            copyCounts(m.ToolCalls),
+		ToolErrors:           copyCounts(m.ToolErrors),
+		BotSilenced:          copyCounts(m.BotSilenced),
+		Experiments:          experiments,
+	}
+}
+
+// copyCounts returns an independent copy of a counter map
+func copyCounts(src map[string]int) map[string]int {
+	dst := make(map[string]int, len(src))
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
 // IncRequest increments total requests and per-agent counters
 func (m *Metrics) IncRequest(agent string) {
 	m.mu.Lock()
@@ -35,9 +125,103 @@ func (m *Metrics) IncHandoff() {
 	m.TotalHandoffs++
 }
 
+// IncHandoffLoop increments the counter of agents bouncing the conversation back and forth
+func (m *Metrics) IncHandoffLoop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.HandoffLoops++
+}
+
 // IncEscalate increments the human intervention counter
 func (m *Metrics) IncEscalate() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.TotalEscalates++
 }
+
+// IncPolicyViolation counts an ActionPlan that broke the agent's policy
+func (m *Metrics) IncPolicyViolation(agent, rule string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.PolicyViolations++
+	m.ViolationsByRule[rule]++
+	m.ViolationsByAgent[agent]++
+}
+
+// IncRejectedPlan counts an ActionPlan that could not be repaired
+func (m *Metrics) IncRejectedPlan() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.RejectedPlans++
+}
+
+// IncRouterDecision counts a router classification and whether it was applied to the conversation
+func (m *Metrics) IncRouterDecision(agent string, applied bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.RouterCalls++
+	m.RouterDecisions[agent]++
+	if applied {
+		m.RouterApplied++
+	}
+}
+
+// IncRouterError counts router calls that failed
+func (m *Metrics) IncRouterError() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.RouterCalls++
+	m.RouterErrors++
+}
+
+// IncPreRoute counts a deterministic pre-routing decision and the rules that triggered it
+func (m *Metrics) IncPreRoute(rules []string, escalate bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.PreRouted++
+	if escalate {
+		m.PreRouterEscalations++
+	}
+	for _, r := range rules {
+		m.PreRouterRules[r]++
+	}
+}
+
+// IncToolCall counts a tool execution and whether it failed
+func (m *Metrics) IncToolCall(tool string, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ToolCalls[tool]++
+	if !ok {
+		m.ToolErrors[tool]++
+	}
+}
+
+// IncBotSilenced counts customer messages received while the bot was not in charge, by mode
+func (m *Metrics) IncBotSilenced(mode Mode) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.BotSilenced[string(mode)]++
+}
+
+// IncVariantPlan counts a plan produced under an experiment variant and refreshes its rates
+func (m *Metrics) IncVariantPlan(key, action string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	st, ok := m.Experiments[key]
+	if !ok {
+		st = &VariantStats{Actions: make(map[string]int)}
+		m.Experiments[key] = st
+	}
+	st.Runs++
+	st.Actions[action]++
+	switch action {
+	case "escalate":
+		st.Escalations++
+	case "change_agent":
+		st.Handoffs++
+	}
+	st.EscalationRate = float64(st.Escalations) / float64(st.Runs)
+	st.HandoffRate = float64(st.Handoffs) / float64(st.Runs)
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+func newMetrics() *Metrics {
+	return &Metrics{
+		RequestsByAgent:   make(map[string]int),
+		ViolationsByRule:  make(map[string]int),
+		ViolationsByAgent: make(map[string]int),
+		RouterDecisions:   make(map[string]int),
+		PreRouterRules:    make(map[string]int),
+		ToolCalls:         make(map[string]int),
+		ToolErrors:        make(map[string]int),
+		BotSilenced:       make(map[string]int),
+		Experiments:       make(map[string]*VariantStats),
+	}
+}
+
+// touch bumps every counter once so a field missing from Snapshot shows up in the comparison
+func touch(m *Metrics) {
+	m.IncRequest("a")
+	m.IncHandoff()
+	m.IncHandoffLoop()
+	m.IncEscalate()
+	m.IncPolicyViolation("a", "r")
+	m.IncRejectedPlan()
+	m.IncRouterDecision("a", true)
+	m.IncRouterError()
+	m.IncPreRoute([]string{"hacker"}, true)
+	m.IncToolCall("abrir_med", false)
+	m.IncBotSilenced(ModeHuman)
+	m.IncVariantPlan("exp/b", "escalate")
+}
+
+func TestSnapshotCopiesEveryField(t *testing.T) {
+	m := newMetrics()
+	touch(m)
+
+	snap := m.Snapshot()
+	want, _ := json.Marshal(m)
+	got, _ := json.Marshal(snap)
+	if string(got) != string(want) {
+		t.Fatalf("snapshot differs:\n got %s\nwant %s", got, want)
+	}
+
+	// Later increments must not leak into the copy
+	touch(m)
+	if snap.TotalRequests != 1 || snap.RequestsByAgent["a"] != 1 || snap.Experiments["exp/b"].Runs != 1 || snap.Experiments["exp/b"].Actions["escalate"] != 1 {
+		t.Errorf("snapshot shares state with the live metrics: %+v", snap)
+	}
+}
+
+func TestSnapshotWhileCounting(t *testing.T) {
+	m := newMetrics()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				touch(m)
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		if _, err := json.Marshal(m.Snapshot()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	if got := m.Snapshot().TotalRequests; got != 800 {
+		t.Errorf("TotalRequests = %d, want 800", got)
+	}
+}
@@ -0,0 +1,24 @@
+package core
+
+import "fmt"
+
+// Mode tells who is in charge of answering a conversation
+type Mode string
+
+const (
+	// ModeBot is the default: every message goes through the agent brains
+	ModeBot Mode = "bot"
+	// ModeHuman means an operator owns the conversation; messages are forwarded to the human queue
+	ModeHuman Mode = "human"
+	// ModePaused silences the bot without forwarding messages to anyone
+	ModePaused Mode = "paused"
+)
+
+// ParseMode validates a mode name coming from the API
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(s); m {
+	case ModeBot, ModeHuman, ModePaused:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown mode %q", s)
+}
@@ -0,0 +1,121 @@
+package core
+
+import "fmt"
+
+// KnownActions lists every action the orchestrator knows how to execute
+var KnownActions = []string{"reply", "ask", "collect_data", "change_agent", "escalate", "call_api", "end"}
+
+// Violation describes a single policy rule broken by an ActionPlan
+type Violation struct {
+	Rule   string
+	Detail string
+}
+
+// EnforcePolicy validates a plan against the emitting agent's contract and repairs it when possible.
+// It returns the (possibly repaired) plan, the violations found and false when the plan must be rejected.
+func EnforcePolicy(spec AgentSpec, plan ActionPlan) (ActionPlan, []Violation, bool) {
+	var violations []Violation
+
+	if !isKnownAction(plan.Action) {
+		violations = append(violations, Violation{
+			Rule:   "unknown_action",
+			Detail: fmt.Sprintf("action %q is not supported", plan.Action),
+		})
+		return downgradeToReply(plan, violations)
+	}
+
+	if !spec.AllowsAction(plan.Action) {
+		violations = append(violations, Violation{
+			Rule:   "action_not_allowed",
+			Detail: fmt.Sprintf("agent %s may not use action %q", spec.Name, plan.Action),
+		})
+		return downgradeToReply(plan, violations)
+	}
+
+	if plan.Action == "call_api" {
+		return enforceToolPolicy(spec, plan, violations)
+	}
+
+	if plan.Action != "change_agent" {
+		return plan, violations, true
+	}
+
+	// Models often send "null" or nothing when they mean "back to reception"
+	if plan.ChangeAgent == "" || plan.ChangeAgent == "null" {
+		plan.ChangeAgent = DefaultAgent
+	}
+
+	switch _, registered := LookupAgent(plan.ChangeAgent); {
+	case plan.ChangeAgent == spec.Name:
+		violations = append(violations, Violation{
+			Rule:   "self_handoff",
+			Detail: fmt.Sprintf("agent %s tried to transfer to itself", spec.Name),
+		})
+		return downgradeToReply(plan, violations)
+	case !registered:
+		violations = append(violations, Violation{
+			Rule:   "unknown_agent",
+			Detail: fmt.Sprintf("change_agent %q is not a registered agent", plan.ChangeAgent),
+		})
+	case !spec.CanHandoffTo(plan.ChangeAgent):
+		violations = append(violations, Violation{
+			Rule:   "handoff_not_allowed",
+			Detail: fmt.Sprintf("agent %s may not transfer to %s", spec.Name, plan.ChangeAgent),
+		})
+	default:
+		return plan, violations, true
+	}
+
+	// Invalid target: fall back to reception when permitted, otherwise answer in place
+	if spec.Name != DefaultAgent && spec.CanHandoffTo(DefaultAgent) {
+		plan.ChangeAgent = DefaultAgent
+		return plan, violations, true
+	}
+	return downgradeToReply(plan, violations)
+}
+
+// enforceToolPolicy makes sure a call_api plan names a tool the agent is allowed to use
+func enforceToolPolicy(spec AgentSpec, plan ActionPlan, violations []Violation) (ActionPlan, []Violation, bool) {
+	// A single-tool agent that forgot the tool name clearly meant its only tool
+	if plan.Tool == "" && len(spec.Tools) == 1 {
+		violations = append(violations, Violation{
+			Rule:   "missing_tool",
+			Detail: fmt.Sprintf("call_api without tool, assumed %s", spec.Tools[0]),
+		})
+		plan.Tool = spec.Tools[0]
+	}
+
+	if !spec.AllowsTool(plan.Tool) {
+		violations = append(violations, Violation{
+			Rule:   "tool_not_allowed",
+			Detail: fmt.Sprintf("agent %s may not call tool %q", spec.Name, plan.Tool),
+		})
+		return downgradeToReply(plan, violations)
+	}
+	return plan, violations, true
+}
+
+// downgradeToReply turns an invalid plan into a plain reply, rejecting it when there is nothing to say
+func downgradeToReply(plan ActionPlan, violations []Violation) (ActionPlan, []Violation, bool) {
+	if plan.Message == "" && plan.NextQuestion == "" {
+		return plan, violations, false
+	}
+
+	plan.Action = "reply"
+	if plan.Message == "" {
+		plan.Message = plan.NextQuestion
+	}
+	plan.ChangeAgent = ""
+	plan.Tool = ""
+	plan.ToolArgs = nil
+	return plan, violations, true
+}
+
+func isKnownAction(action string) bool {
+	for _, a := range KnownActions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultAgent is the reception agent every new conversation starts with
+const DefaultAgent = "atendimento_geral"
+
+// AgentSpec describes a specialist agent and the contract the orchestrator enforces on it
+type AgentSpec struct {
+	// Name is the technical identifier used in change_agent and routing
+	Name string
+	// Description tells other agents (and the router) when to transfer to this one
+	Description string
+	// Actions lists the ActionPlan actions this agent may emit
+	Actions []string
+	// Handoffs lists the agents this one may transfer to (nil means every other registered agent)
+	Handoffs []string
+	// Tools lists the tools this agent may request with "call_api"
+	Tools []string
+	// Form names the slot-filling form (internal/slots) whose data this agent collects
+	Form string
+	// KBSections restricts RAG retrieval to these knowledge base sections (nil means all)
+	KBSections []string
+	// Security marks fraud and account-takeover specialists: their escalations always get high priority
+	Security bool
+	// Brain implements the agent logic
+	Brain AgentBrain
+}
+
+var registry = struct {
+	mu     sync.RWMutex
+	agents map[string]AgentSpec
+}{agents: make(map[string]AgentSpec)}
+
+// RegisterAgent makes an agent available to the orchestrator; it panics on invalid or duplicate names
+func RegisterAgent(spec AgentSpec) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if spec.Name == "" || spec.Brain == nil {
+		panic("core: RegisterAgent requires a name and a brain")
+	}
+	if _, dup := registry.agents[spec.Name]; dup {
+		panic("core: RegisterAgent called twice for " + spec.Name)
+	}
+	registry.agents[spec.Name] = spec
+}
+
+// LookupAgent returns the registered agent with the given name
+func LookupAgent(name string) (AgentSpec, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	spec, ok := registry.agents[name]
+	return spec, ok
+}
+
+// Agents returns every registered agent sorted by name
+func Agents() []AgentSpec {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	out := make([]AgentSpec, 0, len(registry.agents))
+	for _, spec := range registry.agents {
+		out = append(out, spec)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// AllowsAction reports whether the agent may emit the given action
+func (a AgentSpec) AllowsAction(action string) bool {
+	for _, act := range a.Actions {
+		if act == action {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsTool reports whether the agent may request the given tool
+func (a AgentSpec) AllowsTool(tool string) bool {
+	for _, t := range a.Tools {
+		if t == tool {
+			return true
+		}
+	}
+	return false
+}
+
+// HandoffTargets resolves the agents this one may transfer to
+func (a AgentSpec) HandoffTargets() []string {
+	if a.Handoffs != nil {
+		return a.Handoffs
+	}
+
+	var out []string
+	for _, spec := range Agents() {
+		if spec.Name != a.Name {
+			out = append(out, spec.Name)
+		}
+	}
+	return out
+}
+
+// CanHandoffTo reports whether the agent may transfer the conversation to target
+func (a AgentSpec) CanHandoffTo(target string) bool {
+	for _, t := range a.HandoffTargets() {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// ActionList formats the allowed actions for the JSON schema section of a prompt
+func (a AgentSpec) ActionList() string {
+	return strings.Join(a.Actions, " | ")
+}
+
+// TransferRules renders the handoff targets of an agent as a prompt bullet list
+func TransferRules(name string) string {
+	spec, ok := LookupAgent(name)
+	if !ok {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, target := range spec.HandoffTargets() {
+		t, ok := LookupAgent(target)
+		if !ok {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("- %q: %s\n", t.Name, t.Description))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// HandoffList formats the handoff targets for the "change_agent" field of a prompt schema
+func HandoffList(name string) string {
+	spec, ok := LookupAgent(name)
+	if !ok {
+		return "null"
+	}
+	targets := append([]string(nil), spec.HandoffTargets()...)
+	return strings.Join(append(targets, "null"), " | ")
+}
@@ -0,0 +1,40 @@
+package core
+
+import "sync"
+
+// Shadow is a candidate brain that receives the same inputs as a production agent
+// without ever answering the customer
+type Shadow struct {
+	// Name identifies the candidate in reports (e.g. golpe_med_v2)
+	Name string
+	// Brain is the candidate logic
+	Brain AgentBrain
+}
+
+var shadows = struct {
+	mu sync.RWMutex
+	m  map[string]Shadow
+}{m: make(map[string]Shadow)}
+
+// RegisterShadow attaches a candidate brain to a production agent; it panics on invalid or duplicate shadows
+func RegisterShadow(agent string, s Shadow) {
+	shadows.mu.Lock()
+	defer shadows.mu.Unlock()
+
+	if agent == "" || s.Name == "" || s.Brain == nil {
+		panic("core: RegisterShadow requires an agent, a name and a brain")
+	}
+	if _, dup := shadows.m[agent]; dup {
+		panic("core: RegisterShadow called twice for " + agent)
+	}
+	shadows.m[agent] = s
+}
+
+// LookupShadow returns the candidate brain running in shadow for an agent
+func LookupShadow(agent string) (Shadow, bool) {
+	shadows.mu.RLock()
+	defer shadows.mu.RUnlock()
+
+	s, ok := shadows.m[agent]
+	return s, ok
+}
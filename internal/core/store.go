@@ -0,0 +1,62 @@
+package core
+
+import "time"
+
+// Store defines the persistence contract for conversation history and agent state
+type Store interface {
+	// Add appends a new message to the conversation history
+	Add(convID string, msg ChatMessage)
+
+	// Get retrieves a copy of the conversation history
+	Get(convID string) []ChatMessage
+
+	// GetAgent returns the specialist agent assigned to the conversation
+	GetAgent(convID string) (string, bool)
+
+	// SetAgent updates the active specialist agent for the conversation
+	SetAgent(convID, agent string)
+
+	// GetSummary returns the rolling summary of the turns already dropped from the history
+	GetSummary(convID string) string
+
+	// Compact replaces the summary and keeps only the last keep messages of the history
+	Compact(convID, summary string, keep int)
+
+	// GetAttrs returns the key/value attributes attached to the conversation (slots, flags...)
+	GetAttrs(convID string) map[string]string
+
+	// SetAttr attaches a key/value attribute to the conversation (an empty value removes it)
+	SetAttr(convID, key, value string)
+
+	// GetMode returns who is answering the conversation (ModeBot when never set)
+	GetMode(convID string) Mode
+
+	// SetMode hands the conversation to the bot, a human operator or pauses it
+	SetMode(convID string, mode Mode)
+
+	// LastActivity returns when the conversation last received a message
+	LastActivity(convID string) (time.Time, bool)
+
+	// Reset closes the session so the next message starts from scratch
+	Reset(convID string)
+
+	// IdleConversations lists the bot-mode conversations idle for longer than ttl
+	IdleConversations(ttl time.Duration) []string
+
+	// ExpireIfIdle resets the conversation only if it is still idle and answered by the bot
+	ExpireIfIdle(convID string, ttl time.Duration) bool
+
+	// PrintAll dumps all active conversations to the console for debugging
+	PrintAll()
+}
+
+// storeSnapshot is the serializable state shared by the store implementations
+type storeSnapshot struct {
+	Items  map[string][]ChatMessage     `json:"items"`
+	Agents map[string]string            `json:"agents"`
+	Seen   map[string]time.Time         `json:"seen"`
+	Attrs  map[string]map[string]string `json:"attrs"`
+	Modes  map[string]Mode              `json:"modes,omitempty"`
+
+	Summaries map[string]string `json:"summaries,omitempty"`
+}
@@ -0,0 +1,34 @@
+package core
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// StartSweeper periodically evicts conversations idle for longer than ttl until ctx is done.
+// expire is called for every idle candidate and must re-check it under the caller's own
+// locks (e.g. the per-conversation lock of the API), returning whether it was reset.
+func StartSweeper(ctx context.Context, s Store, ttl, interval time.Duration, expire func(convID string) bool) {
+	if ttl <= 0 || interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for _, id := range s.IdleConversations(ttl) {
+					if expire(id) {
+						log.Printf("conv=%s event=session_expired ttl=%v", id, ttl)
+					}
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,29 @@
+package core
+
+import (
+	"encoding/json"
+)
+
+// ToolResult is the outcome of a tool execution fed back to the agent that requested it
+type ToolResult struct {
+	Tool  string         `json:"tool"`
+	OK    bool           `json:"ok"`
+	Data  map[string]any `json:"data,omitempty"`
+	Error string         `json:"error,omitempty"`
+}
+
+// PromptBlock renders the result for injection in the agent's prompt
+func (r *ToolResult) PromptBlock() string {
+	if r == nil {
+		return ""
+	}
+
+	status := "sucesso"
+	if !r.OK {
+		status = "falha"
+	}
+	b, _ := json.Marshal(r)
+
+	return "RESULTADO DA TOOL \"" + r.Tool + "\" (" + status + "):\n" + string(b) + "\n" +
+		"Use este resultado para redigir a mensagem final ao cliente. Não chame a mesma tool novamente.\n\n"
+}
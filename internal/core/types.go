@@ -20,12 +20,39 @@ type ActionPlan struct {
 	ChangeAgent   string  `json:"change_agent"`
 	HandoffReason string  `json:"handoff_reason"`
 	Confidence    float64 `json:"confidence"`
+
+	// Tool and ToolArgs describe the tool to execute when Action is "call_api"
+	Tool     string         `json:"tool,omitempty"`
+	ToolArgs map[string]any `json:"tool_args,omitempty"`
+
+	// Slots reports structured data the model extracted from the conversation
+	Slots map[string]string `json:"slots,omitempty"`
+
+	// PromptVersion identifies the system prompt that produced the plan (set by the brain)
+	PromptVersion string `json:"-"`
+}
+
+// BrainInput bundles everything an agent receives for a single turn
+type BrainInput struct {
+	TraceID        string
+	ConversationID string
+	History        []ChatMessage
+	UserMessage    string
+	RAGContext     string
+	// Handoff is set when another agent transferred the conversation during this turn
+	Handoff *HandoffNote
+	// ToolResult is set when the agent is called back after one of its tools ran
+	ToolResult *ToolResult
+	// Slots is the rendered slot-filling state of the agent's form, if it has one
+	Slots string
+	// Summary condenses the turns that no longer fit in History
+	Summary string
 }
 
 // AgentBrain defines the interface for specialized agent logic
 type AgentBrain interface {
 	// Run executes the agent's logic and returns an ActionPlan
-	Run(ctx context.Context, client any, traceID string, history []ChatMessage, userMessage string, ragContext string) (ActionPlan, error)
+	Run(ctx context.Context, client any, in BrainInput) (ActionPlan, error)
 }
 
 // Citation represents a reference from the Knowledge Base (RAG)
@@ -0,0 +1,262 @@
+package escalation
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+)
+
+// Ticket status values
+const (
+	StatusOpen    = "open"
+	StatusClaimed = "claimed"
+	StatusClosed  = "closed"
+)
+
+// Ticket priorities: security cases are always served first
+const (
+	PriorityHigh   = "alta"
+	PriorityNormal = "normal"
+)
+
+var (
+	// ErrNotFound is returned when a ticket ID does not exist
+	ErrNotFound = errors.New("escalation not found")
+	// ErrConflict is returned when an operation does not match the ticket state or owner
+	ErrConflict = errors.New("escalation state conflict")
+)
+
+// Ticket is an escalated conversation waiting for (or handled by) a human operator
+type Ticket struct {
+	ID             string             `json:"id"`
+	ConversationID string             `json:"conversation_id"`
+	TraceID        string             `json:"trace_id"`
+	Agent          string             `json:"agent"`
+	Reason         string             `json:"reason"`
+	Priority       string             `json:"priority"`
+	Status         string             `json:"status"`
+	Operator       string             `json:"operator,omitempty"`
+	Transcript     []core.ChatMessage `json:"transcript"`
+	CreatedAt      time.Time          `json:"created_at"`
+	ClaimedAt      *time.Time         `json:"claimed_at,omitempty"`
+	ClosedAt       *time.Time         `json:"closed_at,omitempty"`
+}
+
+// Request describes a new escalation coming from the orchestrator
+type Request struct {
+	ConversationID string
+	TraceID        string
+	Agent          string
+	Reason         string
+	Security       bool
+	Transcript     []core.ChatMessage
+}
+
+// Queue holds escalated conversations and the operator replies waiting for delivery
+type Queue struct {
+	mu      sync.Mutex
+	tickets map[string]*Ticket
+	outbox  map[string][]string
+}
+
+// NewQueue creates an empty in-memory escalation queue
+func NewQueue() *Queue {
+	return &Queue{
+		tickets: make(map[string]*Ticket),
+		outbox:  make(map[string][]string),
+	}
+}
+
+// Enqueue opens a ticket for the conversation, or refreshes the active one instead of duplicating it
+func (q *Queue) Enqueue(req Request) Ticket {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	priority := PriorityNormal
+	if req.Security {
+		priority = PriorityHigh
+	}
+
+	if t := q.activeLocked(req.ConversationID); t != nil {
+		t.Transcript = req.Transcript
+		if req.Reason != "" && !strings.Contains(t.Reason, req.Reason) {
+			t.Reason += "; " + req.Reason
+		}
+		if priority == PriorityHigh {
+			t.Priority = PriorityHigh
+		}
+		return *t
+	}
+
+	t := &Ticket{
+		ID:             newTicketID(),
+		ConversationID: req.ConversationID,
+		TraceID:        req.TraceID,
+		Agent:          req.Agent,
+		Reason:         req.Reason,
+		Priority:       priority,
+		Status:         StatusOpen,
+		Transcript:     req.Transcript,
+		CreatedAt:      time.Now(),
+	}
+	q.tickets[t.ID] = t
+	return *t
+}
+
+// List returns tickets with the given status (empty means open and claimed), by priority then age
+func (q *Queue) List(status string) []Ticket {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	out := make([]Ticket, 0)
+	for _, t := range q.tickets {
+		switch {
+		case status == "" && t.Status == StatusClosed:
+			continue
+		case status != "" && t.Status != status:
+			continue
+		}
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Priority != out[j].Priority {
+			return out[i].Priority == PriorityHigh
+		}
+		return out[i].CreatedAt.Before(out[j].CreatedAt)
+	})
+	return out
+}
+
+// Get returns the ticket with the given ID
+func (q *Queue) Get(id string) (Ticket, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	t, ok := q.tickets[id]
+	if !ok {
+		return Ticket{}, ErrNotFound
+	}
+	return *t, nil
+}
+
+// Active returns the open or claimed ticket of a conversation, if any
+func (q *Queue) Active(convID string) (Ticket, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if t := q.activeLocked(convID); t != nil {
+		return *t, true
+	}
+	return Ticket{}, false
+}
+
+// Forward appends a customer message to the conversation's active ticket; it reports false when there is none
+func (q *Queue) Forward(convID string, msg core.ChatMessage) (Ticket, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	t := q.activeLocked(convID)
+	if t == nil {
+		return Ticket{}, false
+	}
+	t.Transcript = append(t.Transcript, msg)
+	return *t, true
+}
+
+// Claim assigns an open ticket to an operator
+func (q *Queue) Claim(id, operator string) (Ticket, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	t, ok := q.tickets[id]
+	if !ok {
+		return Ticket{}, ErrNotFound
+	}
+	if t.Status != StatusOpen {
+		return Ticket{}, fmt.Errorf("%w: ticket is %s by %q", ErrConflict, t.Status, t.Operator)
+	}
+
+	now := time.Now()
+	t.Status = StatusClaimed
+	t.Operator = operator
+	t.ClaimedAt = &now
+	return *t, nil
+}
+
+// Reply queues an operator message for delivery; only the operator who claimed the ticket may reply
+func (q *Queue) Reply(id, operator, message string) (Ticket, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	t, err := q.ownedLocked(id, operator)
+	if err != nil {
+		return Ticket{}, err
+	}
+
+	msg := core.ChatMessage{Role: "human", Text: message, Timestamp: time.Now()}
+	t.Transcript = append(t.Transcript, msg)
+	q.outbox[t.ConversationID] = append(q.outbox[t.ConversationID], message)
+	return *t, nil
+}
+
+// Close finishes a ticket; only the operator who claimed it may close it
+func (q *Queue) Close(id, operator string) (Ticket, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	t, err := q.ownedLocked(id, operator)
+	if err != nil {
+		return Ticket{}, err
+	}
+
+	now := time.Now()
+	t.Status = StatusClosed
+	t.ClosedAt = &now
+	return *t, nil
+}
+
+// DrainOutbox returns and clears the operator messages pending delivery to the customer
+func (q *Queue) DrainOutbox(convID string) []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	msgs := q.outbox[convID]
+	delete(q.outbox, convID)
+	return msgs
+}
+
+// activeLocked finds the open or claimed ticket of a conversation; callers must hold q.mu
+func (q *Queue) activeLocked(convID string) *Ticket {
+	for _, t := range q.tickets {
+		if t.ConversationID == convID && t.Status != StatusClosed {
+			return t
+		}
+	}
+	return nil
+}
+
+// ownedLocked returns a claimed ticket after checking its owner; callers must hold q.mu
+func (q *Queue) ownedLocked(id, operator string) (*Ticket, error) {
+	t, ok := q.tickets[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if t.Status != StatusClaimed || t.Operator != operator {
+		return nil, fmt.Errorf("%w: ticket must be claimed by %q first", ErrConflict, operator)
+	}
+	return t, nil
+}
+
+// newTicketID generates a short ticket number such as ESC-1A2B3C4D
+func newTicketID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return "ESC-" + strings.ToUpper(hex.EncodeToString(b))
+}
@@ -0,0 +1,101 @@
+package escalation
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+)
+
+func TestEnqueueRefreshesActiveTicket(t *testing.T) {
+	q := NewQueue()
+	first := q.Enqueue(Request{ConversationID: "c1", Agent: "golpe_med", Reason: "cliente pediu humano"})
+	if first.Status != StatusOpen || first.Priority != PriorityNormal {
+		t.Fatalf("new ticket = %+v", first)
+	}
+
+	again := q.Enqueue(Request{ConversationID: "c1", Reason: "invasao", Security: true,
+		Transcript: []core.ChatMessage{{Role: "user", Text: "invadiram minha conta"}}})
+	if again.ID != first.ID {
+		t.Fatalf("second escalation opened %s, want %s refreshed", again.ID, first.ID)
+	}
+	if again.Reason != "cliente pediu humano; invasao" || again.Priority != PriorityHigh || len(again.Transcript) != 1 {
+		t.Errorf("refreshed ticket = %+v", again)
+	}
+	if n := len(q.List("")); n != 1 {
+		t.Errorf("%d active tickets, want 1", n)
+	}
+}
+
+func TestListOrdersByPriorityThenAge(t *testing.T) {
+	q := NewQueue()
+	old := q.Enqueue(Request{ConversationID: "old"})
+	time.Sleep(time.Millisecond)
+	newer := q.Enqueue(Request{ConversationID: "newer"})
+	urgent := q.Enqueue(Request{ConversationID: "urgent", Security: true})
+
+	got := q.List("")
+	if len(got) != 3 || got[0].ID != urgent.ID || got[1].ID != old.ID || got[2].ID != newer.ID {
+		t.Errorf("order = %v", ids(got))
+	}
+}
+
+func TestTicketLifecycle(t *testing.T) {
+	q := NewQueue()
+	tk := q.Enqueue(Request{ConversationID: "c1"})
+
+	if _, err := q.Reply(tk.ID, "ana", "oi"); !errors.Is(err, ErrConflict) {
+		t.Errorf("reply before claim: err = %v", err)
+	}
+	if _, err := q.Claim(tk.ID, "ana"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Claim(tk.ID, "bia"); !errors.Is(err, ErrConflict) {
+		t.Errorf("double claim: err = %v", err)
+	}
+	if _, err := q.Reply(tk.ID, "bia", "oi"); !errors.Is(err, ErrConflict) {
+		t.Errorf("reply by another operator: err = %v", err)
+	}
+
+	if _, ok := q.Forward("c1", core.ChatMessage{Role: "user", Text: "alô?"}); !ok {
+		t.Error("customer message not forwarded to the active ticket")
+	}
+	if _, err := q.Reply(tk.ID, "ana", "Olá, sou a Ana."); err != nil {
+		t.Fatal(err)
+	}
+	if msgs := q.DrainOutbox("c1"); len(msgs) != 1 || msgs[0] != "Olá, sou a Ana." {
+		t.Errorf("outbox = %v", msgs)
+	}
+	if msgs := q.DrainOutbox("c1"); len(msgs) != 0 {
+		t.Errorf("outbox not cleared: %v", msgs)
+	}
+
+	closed, err := q.Close(tk.ID, "ana")
+	if err != nil || closed.Status != StatusClosed || closed.ClosedAt == nil {
+		t.Fatalf("Close = %+v, %v", closed, err)
+	}
+	if len(closed.Transcript) != 2 {
+		t.Errorf("transcript = %+v", closed.Transcript)
+	}
+	if _, ok := q.Active("c1"); ok {
+		t.Error("closed ticket still active")
+	}
+	if _, ok := q.Forward("c1", core.ChatMessage{Text: "x"}); ok {
+		t.Error("message forwarded to a closed ticket")
+	}
+	if got := q.List(StatusClosed); len(got) != 1 {
+		t.Errorf("closed list = %v", ids(got))
+	}
+	if _, err := q.Get("ESC-NOPE"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get unknown: err = %v", err)
+	}
+}
+
+func ids(ts []Ticket) []string {
+	out := make([]string, len(ts))
+	for i, t := range ts {
+		out[i] = t.ConversationID
+	}
+	return out
+}
@@ -0,0 +1,145 @@
+package experiment
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
+)
+
+// Variant is one arm of an experiment: an optional prompt template and model override
+type Variant struct {
+	Name     string `json:"name"`
+	Weight   int    `json:"weight"`
+	Template string `json:"template"`
+	Model    string `json:"model"`
+}
+
+// Experiment splits the traffic of one agent between variants
+type Experiment struct {
+	Name     string    `json:"name"`
+	Agent    string    `json:"agent"`
+	Variants []Variant `json:"variants"`
+}
+
+// Set holds the active experiments, at most one per agent
+type Set struct {
+	byAgent map[string]Experiment
+}
+
+// New validates the experiments and indexes them by agent
+func New(exps []Experiment) (*Set, error) {
+	s := &Set{byAgent: make(map[string]Experiment)}
+	names := make(map[string]bool)
+
+	for _, e := range exps {
+		if e.Name == "" || e.Agent == "" {
+			return nil, fmt.Errorf("experiment: name and agent are required")
+		}
+		if names[e.Name] {
+			return nil, fmt.Errorf("experiment %q: duplicate name", e.Name)
+		}
+		if _, dup := s.byAgent[e.Agent]; dup {
+			return nil, fmt.Errorf("experiment %q: agent %s already has an experiment", e.Name, e.Agent)
+		}
+		if len(e.Variants) == 0 {
+			return nil, fmt.Errorf("experiment %q: at least one variant is required", e.Name)
+		}
+
+		seen := make(map[string]bool)
+		total := 0
+		for _, v := range e.Variants {
+			if v.Name == "" || seen[v.Name] {
+				return nil, fmt.Errorf("experiment %q: variant names must be unique and non-empty", e.Name)
+			}
+			if v.Weight < 0 {
+				return nil, fmt.Errorf("experiment %q: variant %s has a negative weight", e.Name, v.Name)
+			}
+			total += v.Weight
+			seen[v.Name] = true
+		}
+		if total == 0 {
+			return nil, fmt.Errorf("experiment %q: at least one variant needs a positive weight", e.Name)
+		}
+
+		names[e.Name] = true
+		s.byAgent[e.Agent] = e
+	}
+	return s, nil
+}
+
+// LoadFile reads a JSON array of experiments from path
+func LoadFile(path string) (*Set, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var exps []Experiment
+	if err := json.Unmarshal(b, &exps); err != nil {
+		return nil, fmt.Errorf("decode experiments: %w", err)
+	}
+	return New(exps)
+}
+
+// For returns the experiment running on an agent
+func (s *Set) For(agent string) (Experiment, bool) {
+	if s == nil {
+		return Experiment{}, false
+	}
+	e, ok := s.byAgent[agent]
+	return e, ok
+}
+
+// All returns every active experiment sorted by name
+func (s *Set) All() []Experiment {
+	if s == nil {
+		return nil
+	}
+	out := make([]Experiment, 0, len(s.byAgent))
+	for _, e := range s.byAgent {
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Variant returns the variant with the given name
+func (e Experiment) Variant(name string) (Variant, bool) {
+	for _, v := range e.Variants {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
+
+// Bucket deterministically maps a conversation to a variant, proportionally to the weights.
+// A variant with weight 0 is paused and never receives new conversations
+func (e Experiment) Bucket(convID string) Variant {
+	total := 0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+	if total == 0 {
+		return e.Variants[0]
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(e.Name + ":" + convID))
+	n := int(h.Sum32() % uint32(total))
+
+	for _, v := range e.Variants {
+		if n < v.Weight {
+			return v
+		}
+		n -= v.Weight
+	}
+	return e.Variants[len(e.Variants)-1]
+}
+
+// AttrKey is the conversation attribute that pins the assigned variant
+func (e Experiment) AttrKey() string {
+	return "exp." + e.Name
+}
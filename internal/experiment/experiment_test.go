@@ -0,0 +1,74 @@
+package experiment
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNewValidates(t *testing.T) {
+	v := []Variant{{Name: "a", Weight: 1}}
+	cases := []struct {
+		name string
+		exps []Experiment
+	}{
+		{"missing agent", []Experiment{{Name: "e", Variants: v}}},
+		{"duplicate name", []Experiment{{Name: "e", Agent: "x", Variants: v}, {Name: "e", Agent: "y", Variants: v}}},
+		{"two per agent", []Experiment{{Name: "e1", Agent: "x", Variants: v}, {Name: "e2", Agent: "x", Variants: v}}},
+		{"no variants", []Experiment{{Name: "e", Agent: "x"}}},
+		{"duplicate variant", []Experiment{{Name: "e", Agent: "x", Variants: []Variant{{Name: "a", Weight: 1}, {Name: "a", Weight: 1}}}}},
+		{"negative weight", []Experiment{{Name: "e", Agent: "x", Variants: []Variant{{Name: "a", Weight: -1}, {Name: "b", Weight: 2}}}}},
+		{"all weights zero", []Experiment{{Name: "e", Agent: "x", Variants: []Variant{{Name: "a"}, {Name: "b"}}}}},
+	}
+	for _, c := range cases {
+		if _, err := New(c.exps); err == nil {
+			t.Errorf("%s: accepted", c.name)
+		}
+	}
+
+	s, err := New([]Experiment{{Name: "e", Agent: "x", Variants: []Variant{{Name: "a"}, {Name: "b", Weight: 3}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, ok := s.For("x")
+	if !ok || e.Variants[0].Weight != 0 {
+		t.Errorf("zero weight rewritten: %+v", e)
+	}
+	if _, ok := s.For("y"); ok {
+		t.Error("experiment found for an agent without one")
+	}
+}
+
+func TestBucketIsDeterministicAndWeighted(t *testing.T) {
+	e := Experiment{Name: "tom", Agent: "x", Variants: []Variant{{Name: "a", Weight: 1}, {Name: "b", Weight: 3}}}
+
+	counts := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		id := fmt.Sprintf("conv-%d", i)
+		v := e.Bucket(id)
+		if again := e.Bucket(id); again.Name != v.Name {
+			t.Fatalf("%s bucketed to %s then %s", id, v.Name, again.Name)
+		}
+		counts[v.Name]++
+	}
+	// 1:3 split, with a generous margin for the hash
+	if share := float64(counts["b"]) / 4000; share < 0.70 || share > 0.80 {
+		t.Errorf("variant b got %.2f of the traffic, want about 0.75 (%v)", share, counts)
+	}
+}
+
+func TestBucketSkipsZeroWeight(t *testing.T) {
+	e := Experiment{Name: "tom", Agent: "x", Variants: []Variant{{Name: "pausada"}, {Name: "a", Weight: 1}, {Name: "b", Weight: 1}}}
+
+	for i := 0; i < 1000; i++ {
+		if v := e.Bucket(fmt.Sprintf("conv-%d", i)); v.Name == "pausada" {
+			t.Fatalf("conv-%d bucketed to a zero-weight variant", i)
+		}
+	}
+}
+
+func TestNilSet(t *testing.T) {
+	var s *Set
+	if _, ok := s.For("x"); ok || s.All() != nil {
+		t.Error("nil set must behave as empty")
+	}
+}
@@ -0,0 +1,122 @@
+package harness
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/agents/declarative"
+	"github.com/bonettibruno/Jota_ProdOps/internal/api"
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+	"github.com/bonettibruno/Jota_ProdOps/internal/escalation"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm/cassette"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm/provider"
+	"github.com/bonettibruno/Jota_ProdOps/internal/med"
+	"github.com/bonettibruno/Jota_ProdOps/internal/prerouter"
+	"github.com/bonettibruno/Jota_ProdOps/internal/tools"
+)
+
+// Config describes the in-process orchestrator used by offline tools (eval, traffic replay)
+type Config struct {
+	// AgentsDir holds declarative agents to load (empty skips them)
+	AgentsDir string
+	// HistoryLimit is the number of messages kept per conversation (0 means 20)
+	HistoryLimit int
+	// PreRouter disables the deterministic pre-router when false
+	PreRouter bool
+}
+
+// Orchestrator runs the production MessagesHandler in-process with in-memory stores
+type Orchestrator struct {
+	Store       core.Store
+	Escalations *escalation.Queue
+}
+
+// quietStore skips the per-request memory dump, which would flood batch runs
+type quietStore struct {
+	*core.ConversationStore
+}
+
+func (quietStore) PrintAll() {}
+
+// New wires the api package like cmd/server does, but without network listeners or files.
+// The api package keeps global state, so only one Orchestrator may be active per process.
+func New(client llm.Client, cfg Config) (*Orchestrator, error) {
+	if cfg.HistoryLimit <= 0 {
+		cfg.HistoryLimit = 20
+	}
+
+	store := quietStore{core.NewConversationStore(cfg.HistoryLimit)}
+	queue := escalation.NewQueue()
+	cases, err := med.NewStore("")
+	if err != nil {
+		return nil, err
+	}
+	tools.Register(med.NewAbrirTool(cases))
+	tools.Register(med.NewConsultarTool(cases))
+
+	var pr *prerouter.PreRouter
+	if cfg.PreRouter {
+		if pr, err = prerouter.New(prerouter.DefaultRules, 1.0); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.AgentsDir != "" {
+		if _, err := declarative.LoadDir(cfg.AgentsDir); err != nil {
+			return nil, err
+		}
+	}
+
+	api.SetLLMClient(client)
+	api.SetStore(store)
+	api.SetEscalationQueue(queue)
+	api.SetMEDStore(cases)
+	api.SetPreRouter(pr)
+	api.SetSessionTTL(0)
+
+	return &Orchestrator{Store: store, Escalations: queue}, nil
+}
+
+// Send delivers one customer message and returns the decoded response and its latency
+func (o *Orchestrator) Send(ctx context.Context, convID, message string) (api.MessageResponse, time.Duration, error) {
+	body, err := json.Marshal(api.MessageRequest{ConversationID: convID, Message: message})
+	if err != nil {
+		return api.MessageResponse{}, 0, err
+	}
+	req := httptest.NewRequest(http.MethodPost, "/messages", bytes.NewReader(body)).WithContext(ctx)
+	rec := httptest.NewRecorder()
+
+	start := time.Now()
+	api.MessagesHandler(rec, req)
+	latency := time.Since(start)
+
+	if rec.Code != http.StatusOK {
+		return api.MessageResponse{}, latency, fmt.Errorf("status %d: %s", rec.Code, bytes.TrimSpace(rec.Body.Bytes()))
+	}
+	var out api.MessageResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		return api.MessageResponse{}, latency, err
+	}
+	return out, latency, nil
+}
+
+// Client builds the LLM client for an offline run: "live" uses LLM_PROVIDER, "replay" serves the cassette
+func Client(mode, cassettePath string) (llm.Client, error) {
+	switch mode {
+	case "", "live":
+		return provider.FromEnv()
+	case "replay":
+		p, err := cassette.Load(cassettePath)
+		if err != nil {
+			return nil, fmt.Errorf("load cassette: %w", err)
+		}
+		return p, nil
+	}
+	return nil, fmt.Errorf("unknown llm mode %q", mode)
+}
@@ -0,0 +1,304 @@
+package cassette
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm"
+)
+
+// Mode selects how the server talks to the LLM
+type Mode string
+
+const (
+	// Live calls the provider without recording
+	Live Mode = "live"
+	// Record calls the provider and appends every exchange to the cassette
+	Record Mode = "record"
+	// Replay serves answers from the cassette without any network access
+	Replay Mode = "replay"
+)
+
+// ParseMode validates a mode name (empty means live)
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(strings.ToLower(strings.TrimSpace(s))); m {
+	case "":
+		return Live, nil
+	case Live, Record, Replay:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown llm mode %q (use live, record or replay)", s)
+}
+
+// Call kinds stored in the cassette
+const (
+	KindGenerate = "generate"
+	KindRoute    = "route"
+)
+
+// ErrNoRecording is returned in replay when the cassette has no answer for a request
+var ErrNoRecording = errors.New("cassette: no recording for request")
+
+// Entry is one recorded LLM exchange (one JSON line of the cassette)
+type Entry struct {
+	Key     string `json:"key"`
+	Kind    string `json:"kind"`
+	TraceID string `json:"trace_id,omitempty"`
+	Model   string `json:"model,omitempty"`
+
+	// GenerateText request and answer
+	System   string `json:"system,omitempty"`
+	User     string `json:"user,omitempty"`
+	Response string `json:"response,omitempty"`
+
+	// RouteAgent request and answer
+	Message  string              `json:"message,omitempty"`
+	History  []string            `json:"history,omitempty"`
+	Decision *llm.RouterDecision `json:"decision,omitempty"`
+
+	// Error holds the provider error, replayed as-is
+	Error      string    `json:"error,omitempty"`
+	LatencyMs  int64     `json:"latency_ms"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// GenerateKey identifies a GenerateText request. The trace ID is left out so that
+// the same conversation replays across runs.
+func GenerateKey(model, system, user string) string {
+	return key(KindGenerate, model, system, user)
+}
+
+// RouteKey identifies a RouteAgent request
+func RouteKey(model, message string, history []string) string {
+	return key(append([]string{KindRoute, model, message}, history...)...)
+}
+
+// volatile matches values that change on every run (protocol numbers, tool timestamps)
+// and would otherwise make a replayed prompt miss its recording
+var volatile = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`\bMED-[0-9A-Fa-f]{8}\b`), "MED-<id>"},
+	{regexp.MustCompile(`\bESC-[0-9A-Fa-f]{8}\b`), "ESC-<id>"},
+	{regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:\d{2})?`), "<timestamp>"},
+	{regexp.MustCompile(`"(aberto_em|atualizado_em)":"\d{4}-\d{2}-\d{2}"`), `"$1":"<date>"`},
+}
+
+// Normalize replaces run-specific values before a prompt is hashed
+func Normalize(s string) string {
+	for _, v := range volatile {
+		s = v.re.ReplaceAllString(s, v.repl)
+	}
+	return s
+}
+
+func key(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		p = Normalize(p)
+		// Length prefix keeps ("ab","c") and ("a","bc") apart
+		fmt.Fprintf(h, "%d:%s;", len(p), p)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// Recorder is an llm.Client decorator that writes every exchange to a cassette file
+type Recorder struct {
+	next llm.Client
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewRecorder wraps next and appends its exchanges to path (created if missing)
+func NewRecorder(next llm.Client, path string) (*Recorder, error) {
+	if next == nil {
+		return nil, errors.New("cassette: recorder needs a live llm client")
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{next: next, file: f}, nil
+}
+
+// Close flushes and closes the cassette file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// RouteAgent forwards to the live client and records the decision
+func (r *Recorder) RouteAgent(ctx context.Context, traceID string, message string, history []string) (llm.RouterDecision, error) {
+	start := time.Now()
+	dec, err := r.next.RouteAgent(ctx, traceID, message, history)
+
+	model := llm.ModelFrom(ctx)
+	e := Entry{
+		Key:     RouteKey(model, message, history),
+		Kind:    KindRoute,
+		TraceID: traceID,
+		Model:   model,
+		Message: message,
+		History: history,
+	}
+	if err == nil {
+		e.Decision = &dec
+	}
+	r.write(e, start, err)
+	return dec, err
+}
+
+// GenerateText forwards to the live client and records the answer
+func (r *Recorder) GenerateText(ctx context.Context, traceID string, systemPrompt string, userPrompt string) (string, error) {
+	start := time.Now()
+	text, err := r.next.GenerateText(ctx, traceID, systemPrompt, userPrompt)
+
+	model := llm.ModelFrom(ctx)
+	r.write(Entry{
+		Key:      GenerateKey(model, systemPrompt, userPrompt),
+		Kind:     KindGenerate,
+		TraceID:  traceID,
+		Model:    model,
+		System:   systemPrompt,
+		User:     userPrompt,
+		Response: text,
+	}, start, err)
+	return text, err
+}
+
+// write appends the entry; a cassette failure never breaks the live call
+func (r *Recorder) write(e Entry, start time.Time, err error) {
+	// Cancellations belong to the caller, not to the provider: replaying them would be misleading
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	e.LatencyMs = time.Since(start).Milliseconds()
+	e.RecordedAt = time.Now()
+
+	b, mErr := json.Marshal(e)
+	if mErr != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, _ = r.file.Write(append(b, '\n'))
+}
+
+// Player is an llm.Client that answers from a cassette without network access.
+// Identical requests recorded several times are served in recording order; the last
+// answer repeats once they are exhausted.
+type Player struct {
+	mu      sync.Mutex
+	entries map[string][]Entry
+	served  map[string]int
+	misses  int
+}
+
+// Load reads a cassette file
+func Load(path string) (*Player, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	p := &Player{entries: make(map[string][]Entry), served: make(map[string]int)}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("cassette %s line %d: %w", path, line, err)
+		}
+		p.entries[e.Key] = append(p.entries[e.Key], e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Len returns how many exchanges the cassette holds
+func (p *Player) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for _, list := range p.entries {
+		n += len(list)
+	}
+	return n
+}
+
+// Misses returns how many requests had no recording
+func (p *Player) Misses() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.misses
+}
+
+// RouteAgent replays the recorded router decision
+func (p *Player) RouteAgent(ctx context.Context, traceID string, message string, history []string) (llm.RouterDecision, error) {
+	e, err := p.next(RouteKey(llm.ModelFrom(ctx), message, history))
+	if err != nil {
+		return llm.RouterDecision{}, err
+	}
+	if e.Decision == nil {
+		return llm.RouterDecision{}, errors.New(e.Error)
+	}
+	return *e.Decision, nil
+}
+
+// GenerateText replays the recorded answer for the same prompts
+func (p *Player) GenerateText(ctx context.Context, traceID string, systemPrompt string, userPrompt string) (string, error) {
+	e, err := p.next(GenerateKey(llm.ModelFrom(ctx), systemPrompt, userPrompt))
+	if err != nil {
+		return "", err
+	}
+	if e.Error != "" {
+		return "", errors.New(e.Error)
+	}
+	return e.Response, nil
+}
+
+// next returns the following recorded answer for key
+func (p *Player) next(key string) (Entry, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	list := p.entries[key]
+	if len(list) == 0 {
+		p.misses++
+		return Entry{}, fmt.Errorf("%w (key %s)", ErrNoRecording, key)
+	}
+	i := p.served[key]
+	if i >= len(list) {
+		i = len(list) - 1
+	}
+	p.served[key]++
+	return list[i], nil
+}
@@ -0,0 +1,119 @@
+package cassette_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+	"github.com/bonettibruno/Jota_ProdOps/internal/harness"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm/cassette"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm/fake"
+)
+
+func TestRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	live := fake.New().
+		On(fake.Rule{User: "a", Response: "first", Times: 1}).
+		On(fake.Rule{User: "a", Response: "second"}).
+		On(fake.Rule{User: "boom", Err: errors.New("quota exceeded")})
+
+	rec, err := cassette.NewRecorder(live, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	rec.GenerateText(ctx, "t1", "sys", "a")
+	rec.GenerateText(ctx, "t2", "sys", "a")
+	rec.GenerateText(ctx, "t3", "sys", "boom")
+	rec.RouteAgent(ctx, "t4", "oi", []string{"Cliente: oi"})
+	rec.Close()
+
+	p, err := cassette.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Len() != 4 {
+		t.Fatalf("entries = %d, want 4", p.Len())
+	}
+
+	// Identical prompts replay in recording order and the last answer repeats
+	for _, want := range []string{"first", "second", "second"} {
+		if got, err := p.GenerateText(ctx, "other-trace", "sys", "a"); err != nil || got != want {
+			t.Fatalf("GenerateText = %q, %v; want %q", got, err, want)
+		}
+	}
+	if _, err := p.GenerateText(ctx, "", "sys", "boom"); err == nil || err.Error() != "quota exceeded" {
+		t.Errorf("recorded error not replayed: %v", err)
+	}
+	if dec, err := p.RouteAgent(ctx, "", "oi", []string{"Cliente: oi"}); err != nil || dec.Agent != core.DefaultAgent {
+		t.Errorf("RouteAgent = %+v, %v", dec, err)
+	}
+
+	// A model override is part of the key
+	if _, err := p.GenerateText(llm.WithModel(ctx, "other-model"), "", "sys", "a"); !errors.Is(err, cassette.ErrNoRecording) {
+		t.Errorf("err = %v, want ErrNoRecording", err)
+	}
+	if p.Misses() != 1 {
+		t.Errorf("misses = %d, want 1", p.Misses())
+	}
+}
+
+func TestNormalizeVolatileFields(t *testing.T) {
+	a := `RESULTADO DA TOOL "abrir_med" (sucesso): {"protocolo":"MED-1A2B3C4D","aberto_em":"2026-10-17","recorded":"2026-10-17T10:00:00Z"}`
+	b := `RESULTADO DA TOOL "abrir_med" (sucesso): {"protocolo":"MED-99FF00AA","aberto_em":"2026-10-18","recorded":"2026-10-18T11:30:05.123-03:00"}`
+	if cassette.GenerateKey("", "s", a) != cassette.GenerateKey("", "s", b) {
+		t.Errorf("volatile fields change the key:\n%s\n%s", cassette.Normalize(a), cassette.Normalize(b))
+	}
+	if cassette.GenerateKey("", "s", "valor 100") == cassette.GenerateKey("", "s", "valor 200") {
+		t.Error("different prompts share a key")
+	}
+}
+
+// TestReplayToolConversation records a conversation that opens a MED case and replays it
+// on a fresh orchestrator, where the case gets a new protocol number and timestamps
+func TestReplayToolConversation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	const msg = "paguei R$ 250,00 ontem para a chave golpista@gmail.com, B.O. 2026/12345"
+
+	live := fake.New().
+		On(fake.Rule{User: "RESULTADO DA TOOL", Response: fake.Plan(core.ActionPlan{Action: "reply", Message: "MED aberto."})}).
+		On(fake.Rule{Response: fake.Plan(core.ActionPlan{Action: "call_api", Tool: "abrir_med", Confidence: 0.9})})
+	rec, err := cassette.NewRecorder(live, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := run(t, rec, msg)
+	rec.Close()
+
+	p, err := cassette.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := run(t, p, msg)
+
+	if p.Misses() != 0 {
+		t.Fatalf("replay missed %d recordings", p.Misses())
+	}
+	if replayed.Reply != recorded.Reply || replayed.Tool != "abrir_med" {
+		t.Errorf("replayed %+v, recorded %+v", replayed, recorded)
+	}
+}
+
+// run sends msg to a fresh in-process orchestrator with the conversation already at golpe_med
+func run(t *testing.T, client llm.Client, msg string) struct{ Reply, Tool string } {
+	t.Helper()
+	orch, err := harness.New(client, harness.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orch.Store.SetAgent("c-cassette", "golpe_med")
+
+	res, _, err := orch.Send(context.Background(), "c-cassette", msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return struct{ Reply, Tool string }{res.Reply, res.Tool}
+}
@@ -0,0 +1,19 @@
+package llm
+
+import "context"
+
+type modelKey struct{}
+
+// WithModel asks the client to use a specific model for calls made with ctx (e.g. an experiment variant)
+func WithModel(ctx context.Context, model string) context.Context {
+	if model == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, modelKey{}, model)
+}
+
+// ModelFrom returns the model override carried by ctx, if any
+func ModelFrom(ctx context.Context) string {
+	m, _ := ctx.Value(modelKey{}).(string)
+	return m
+}
@@ -0,0 +1,144 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm"
+)
+
+// Rule scripts one answer of the fake client. Empty matchers match anything.
+type Rule struct {
+	// System and User must be substrings of the prompts for the rule to apply
+	System string
+	User   string
+	// Response is returned as the raw model output (see Plan for ActionPlans)
+	Response string
+	// Err makes the call fail instead of answering
+	Err error
+	// Delay is waited before answering (respecting context cancellation)
+	Delay time.Duration
+	// Times limits how often the rule applies (0 means unlimited)
+	Times int
+
+	used int
+}
+
+// Call records one GenerateText invocation
+type Call struct {
+	System string
+	User   string
+	Model  string
+}
+
+// Client is a scriptable llm.Client for offline tests: the first matching rule answers each call
+type Client struct {
+	mu       sync.Mutex
+	rules    []*Rule
+	route    llm.RouterDecision
+	routeErr error
+	calls    []Call
+	inFlight int
+	maxIn    int
+}
+
+// New creates a fake client without rules; unmatched calls fail
+func New() *Client {
+	return &Client{route: llm.RouterDecision{Agent: core.DefaultAgent, Confidence: 1}}
+}
+
+// On appends a rule and returns the client for chaining
+func (c *Client) On(r Rule) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rules = append(c.rules, &r)
+	return c
+}
+
+// Route sets the decision (or error) returned by RouteAgent
+func (c *Client) Route(dec llm.RouterDecision, err error) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.route, c.routeErr = dec, err
+	return c
+}
+
+// Plan renders an ActionPlan as the JSON a model would return
+func Plan(p core.ActionPlan) string {
+	b, _ := json.Marshal(p)
+	return string(b)
+}
+
+// Calls returns every GenerateText call received so far
+func (c *Client) Calls() []Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Call(nil), c.calls...)
+}
+
+// MaxInFlight reports the highest number of concurrent GenerateText calls observed
+func (c *Client) MaxInFlight() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.maxIn
+}
+
+// RouteAgent returns the scripted router decision
+func (c *Client) RouteAgent(ctx context.Context, traceID string, message string, history []string) (llm.RouterDecision, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.route, c.routeErr
+}
+
+// GenerateText answers with the first rule matching the prompts
+func (c *Client) GenerateText(ctx context.Context, traceID string, systemPrompt string, userPrompt string) (string, error) {
+	c.mu.Lock()
+	c.calls = append(c.calls, Call{System: systemPrompt, User: userPrompt, Model: llm.ModelFrom(ctx)})
+	c.inFlight++
+	if c.inFlight > c.maxIn {
+		c.maxIn = c.inFlight
+	}
+	r := c.match(systemPrompt, userPrompt)
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.inFlight--
+		c.mu.Unlock()
+	}()
+
+	if r == nil {
+		return "", fmt.Errorf("fake llm: no rule matched")
+	}
+	if r.Delay > 0 {
+		select {
+		case <-time.After(r.Delay):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	if r.Err != nil {
+		return "", r.Err
+	}
+	return r.Response, nil
+}
+
+// match finds the first rule that applies and consumes one use of it; callers must hold c.mu
+func (c *Client) match(system, user string) *Rule {
+	for _, r := range c.rules {
+		if r.Times > 0 && r.used >= r.Times {
+			continue
+		}
+		if !strings.Contains(system, r.System) || !strings.Contains(user, r.User) {
+			continue
+		}
+		r.used++
+		return r
+	}
+	return nil
+}
@@ -2,11 +2,9 @@ package gemini
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/bonettibruno/Jota_ProdOps/internal/llm"
@@ -42,22 +40,7 @@ func New() (*Client, error) {
 
 // RouteAgent determines which specialized agent should handle the user request
 func (g *Client) RouteAgent(ctx context.Context, traceID string, message string, history []string) (llm.RouterDecision, error) {
-	system := `Você é o roteador do Jota. 
-Sua saída deve ser EXCLUSIVAMENTE um JSON no formato: {"agent": "nome_do_agente"}
-
-Agentes disponíveis:
-- atendimento_geral (assuntos diversos)
-- open_finance (conexão de bancos, compartilhamento de dados)
-- golpe_med (vítima de golpe, Pix fraudulento, roubo)
-- criacao_conta (abertura de conta)`
-
-	var sb strings.Builder
-	sb.WriteString("trace_id: " + traceID + "\n\n")
-	sb.WriteString("Recent history:\n")
-	for i := 0; i < len(history) && i < 6; i++ {
-		sb.WriteString("- " + history[i] + "\n")
-	}
-	sb.WriteString("\nCurrent message:\n" + message + "\n")
+	system, user := llm.RouterPrompt(traceID, message, history)
 
 	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
 	defer cancel()
@@ -66,7 +49,7 @@ Agentes disponíveis:
 	resp, err := g.c.Models.GenerateContent(
 		ctx,
 		g.model,
-		genai.Text(system+"\n\n"+sb.String()),
+		genai.Text(system+"\n\n"+user),
 		&genai.GenerateContentConfig{
 			ResponseMIMEType: "application/json",
 		},
@@ -78,16 +61,7 @@ Agentes disponíveis:
 	rawText := resp.Text()
 	log.Printf("trace=%s event=router_raw_output text=%s", traceID, rawText)
 
-	var dec llm.RouterDecision
-	if err := json.Unmarshal([]byte(rawText), &dec); err != nil {
-		return llm.RouterDecision{}, fmt.Errorf("router JSON parse failed: %w", err)
-	}
-
-	if dec.Agent == "" {
-		dec.Agent = "atendimento_geral"
-	}
-
-	return dec, nil
+	return llm.ParseRouterDecision(rawText)
 }
 
 // GenerateText sends a prompt to the LLM with system instructions and JSON response format
@@ -98,6 +72,12 @@ func (g *Client) GenerateText(
 	userPrompt string,
 ) (string, error) {
 
+	// Experiments may route a conversation to a different model
+	model := g.model
+	if m := llm.ModelFrom(ctx); m != "" {
+		model = m
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
@@ -112,7 +92,7 @@ func (g *Client) GenerateText(
 
 	resp, err := g.c.Models.GenerateContent(
 		ctx,
-		g.model,
+		model,
 		genai.Text(userPrompt),
 		config,
 	)
@@ -0,0 +1,157 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm"
+)
+
+// Config points the client at any OpenAI-compatible chat completions API
+type Config struct {
+	// BaseURL is the API root, e.g. https://api.openai.com/v1 or http://localhost:11434/v1 (Ollama)
+	BaseURL string
+	// APIKey is sent as a Bearer token when set (local servers usually need none)
+	APIKey string
+	// Model is the default model; experiments may override it per call
+	Model string
+	// JSONMode sends response_format=json_object; disable for servers that reject it
+	JSONMode bool
+}
+
+// Client implements llm.Client over the /chat/completions endpoint
+type Client struct {
+	cfg  Config
+	http *http.Client
+}
+
+// New validates the configuration and creates the client
+func New(cfg Config) (*Client, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("openai: base URL not set")
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("openai: model not set")
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	return &Client{cfg: cfg, http: &http.Client{}}, nil
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type responseFormat struct {
+	Type string `json:"type"`
+}
+
+type chatRequest struct {
+	Model          string          `json:"model"`
+	Messages       []message       `json:"messages"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message message `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// RouteAgent determines which specialized agent should handle the user request
+func (c *Client) RouteAgent(ctx context.Context, traceID string, message string, history []string) (llm.RouterDecision, error) {
+	system, user := llm.RouterPrompt(traceID, message, history)
+
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	raw, err := c.complete(ctx, system, user)
+	if err != nil {
+		return llm.RouterDecision{}, err
+	}
+	log.Printf("trace=%s event=router_raw_output text=%s", traceID, raw)
+
+	return llm.ParseRouterDecision(raw)
+}
+
+// GenerateText sends a prompt with system instructions and JSON response format
+func (c *Client) GenerateText(ctx context.Context, traceID string, systemPrompt string, userPrompt string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	raw, err := c.complete(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		return "", fmt.Errorf("openai generate text failed: %w", err)
+	}
+	return llm.ExtractJSON(raw), nil
+}
+
+// complete performs a single chat completion and returns the first choice's content
+func (c *Client) complete(ctx context.Context, system, user string) (string, error) {
+	model := c.cfg.Model
+	if m := llm.ModelFrom(ctx); m != "" {
+		model = m
+	}
+
+	req := chatRequest{
+		Model: model,
+		Messages: []message{
+			{Role: "system", Content: system},
+			{Role: "user", Content: user},
+		},
+	}
+	if c.cfg.JSONMode {
+		req.ResponseFormat = &responseFormat{Type: "json_object"}
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.BaseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.cfg.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.cfg.APIKey)
+	}
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return "", err
+	}
+
+	var out chatResponse
+	if err := json.Unmarshal(b, &out); err != nil {
+		return "", fmt.Errorf("status %d: decode response: %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if out.Error != nil {
+			return "", fmt.Errorf("status %d: %s", resp.StatusCode, out.Error.Message)
+		}
+		return "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if len(out.Choices) == 0 {
+		return "", fmt.Errorf("empty response: no choices")
+	}
+	return out.Choices[0].Message.Content, nil
+}
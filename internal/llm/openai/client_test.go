@@ -0,0 +1,132 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm"
+)
+
+// server answers every chat completion with content and records the last request
+func server(t *testing.T, status int, body string) (*httptest.Server, *http.Request, *chatRequest) {
+	t.Helper()
+	var (
+		gotReq  http.Request
+		gotBody chatRequest
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotReq = *r
+		if err := json.NewDecoder(r.Body).Decode(&gotBody); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &gotReq, &gotBody
+}
+
+func completion(content string) string {
+	b, _ := json.Marshal(map[string]any{
+		"choices": []any{map[string]any{"message": map[string]string{"role": "assistant", "content": content}}},
+	})
+	return string(b)
+}
+
+func TestGenerateTextRequestMapping(t *testing.T) {
+	srv, req, body := server(t, http.StatusOK, completion("```json\n{\"action\":\"reply\"}\n```"))
+	c, err := New(Config{BaseURL: srv.URL + "/v1/", APIKey: "sk-test", Model: "gpt-4o-mini", JSONMode: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.GenerateText(context.Background(), "t1", "sistema", "usuario")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != `{"action":"reply"}` {
+		t.Errorf("GenerateText = %q, want the JSON without fences", got)
+	}
+	if req.URL.Path != "/v1/chat/completions" || req.Method != http.MethodPost {
+		t.Errorf("request %s %s", req.Method, req.URL.Path)
+	}
+	if a := req.Header.Get("Authorization"); a != "Bearer sk-test" {
+		t.Errorf("Authorization = %q", a)
+	}
+	if body.Model != "gpt-4o-mini" || body.ResponseFormat == nil || body.ResponseFormat.Type != "json_object" {
+		t.Errorf("body = %+v", body)
+	}
+	if len(body.Messages) != 2 || body.Messages[0] != (message{"system", "sistema"}) || body.Messages[1] != (message{"user", "usuario"}) {
+		t.Errorf("messages = %+v", body.Messages)
+	}
+}
+
+func TestModelOverrideAndLocalServer(t *testing.T) {
+	srv, req, body := server(t, http.StatusOK, completion(`{"action":"reply"}`))
+	c, err := New(Config{BaseURL: srv.URL + "/v1", Model: "llama3.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.GenerateText(llm.WithModel(context.Background(), "qwen2.5"), "", "s", "u"); err != nil {
+		t.Fatal(err)
+	}
+	if body.Model != "qwen2.5" {
+		t.Errorf("model = %q, want the experiment override", body.Model)
+	}
+	if body.ResponseFormat != nil {
+		t.Error("response_format sent with JSON mode off")
+	}
+	if a := req.Header.Get("Authorization"); a != "" {
+		t.Errorf("Authorization sent without a key: %q", a)
+	}
+}
+
+func TestRouteAgent(t *testing.T) {
+	srv, _, body := server(t, http.StatusOK, completion(`Claro! {"agent":"golpe_med","confidence":0.9}`))
+	c, _ := New(Config{BaseURL: srv.URL, Model: "m", JSONMode: true})
+
+	dec, err := c.RouteAgent(context.Background(), "t1", "caí num golpe", []string{"Cliente: oi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec.Agent != "golpe_med" {
+		t.Errorf("agent = %q", dec.Agent)
+	}
+	if len(body.Messages) != 2 || body.Messages[0].Role != "system" {
+		t.Errorf("router prompt not sent as system + user: %+v", body.Messages)
+	}
+}
+
+func TestErrorResponses(t *testing.T) {
+	cases := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"api error", http.StatusUnauthorized, `{"error":{"message":"invalid api key"}}`, "openai generate text failed: status 401: invalid api key"},
+		{"bare status", http.StatusBadGateway, `{}`, "openai generate text failed: status 502"},
+		{"no choices", http.StatusOK, `{"choices":[]}`, "openai generate text failed: empty response: no choices"},
+	}
+	for _, tc := range cases {
+		srv, _, _ := server(t, tc.status, tc.body)
+		c, _ := New(Config{BaseURL: srv.URL, Model: "m"})
+		if _, err := c.GenerateText(context.Background(), "", "s", "u"); err == nil || err.Error() != tc.want {
+			t.Errorf("%s: err = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	if _, err := New(Config{Model: "m"}); err == nil {
+		t.Error("missing base URL accepted")
+	}
+	if _, err := New(Config{BaseURL: "http://localhost"}); err == nil {
+		t.Error("missing model accepted")
+	}
+}
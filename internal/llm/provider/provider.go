@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm/gemini"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm/openai"
+)
+
+// Provider names accepted in LLM_PROVIDER
+const (
+	Gemini = "gemini"
+	OpenAI = "openai"
+	Ollama = "ollama"
+)
+
+// Name returns the provider configured in LLM_PROVIDER (gemini by default)
+func Name() string {
+	if p := os.Getenv("LLM_PROVIDER"); p != "" {
+		return p
+	}
+	return Gemini
+}
+
+// FromEnv builds the llm.Client selected by LLM_PROVIDER from its environment variables
+func FromEnv() (llm.Client, error) {
+	switch p := Name(); p {
+	case Gemini:
+		c, err := gemini.New()
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
+	case OpenAI:
+		baseURL, key := env("OPENAI_BASE_URL", "https://api.openai.com/v1"), os.Getenv("OPENAI_API_KEY")
+		// Only a server on this machine may run without a key; remote APIs would answer 401 on every call
+		if key == "" && !isLocal(baseURL) {
+			return nil, fmt.Errorf("OPENAI_API_KEY not set for %s", baseURL)
+		}
+		return newOpenAI(openai.Config{
+			BaseURL:  baseURL,
+			APIKey:   key,
+			Model:    env("OPENAI_MODEL", "gpt-4o-mini"),
+			JSONMode: os.Getenv("OPENAI_JSON_MODE") != "false",
+		})
+	case Ollama:
+		// Ollama exposes the same chat completions API under /v1
+		return newOpenAI(openai.Config{
+			BaseURL:  env("OLLAMA_BASE_URL", "http://localhost:11434/v1"),
+			Model:    env("OLLAMA_MODEL", "llama3.1"),
+			JSONMode: os.Getenv("OLLAMA_JSON_MODE") != "false",
+		})
+	default:
+		return nil, fmt.Errorf("unknown LLM_PROVIDER %q (use gemini, openai or ollama)", p)
+	}
+}
+
+// newOpenAI avoids returning a typed nil inside the llm.Client interface
+func newOpenAI(cfg openai.Config) (llm.Client, error) {
+	c, err := openai.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// isLocal reports whether baseURL points at a loopback host
+func isLocal(baseURL string) bool {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// env returns the variable or its default
+func env(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
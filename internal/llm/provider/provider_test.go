@@ -0,0 +1,85 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFromEnvOpenAIRequiresKeyForRemoteURL(t *testing.T) {
+	t.Setenv("LLM_PROVIDER", OpenAI)
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("OPENAI_BASE_URL", "")
+
+	if _, err := FromEnv(); err == nil || !strings.Contains(err.Error(), "OPENAI_API_KEY") {
+		t.Errorf("default URL without key: err = %v", err)
+	}
+
+	t.Setenv("OPENAI_BASE_URL", "https://llm.example.com/v1")
+	if _, err := FromEnv(); err == nil {
+		t.Error("remote URL without key accepted")
+	}
+
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	if _, err := FromEnv(); err != nil {
+		t.Errorf("remote URL with key: %v", err)
+	}
+}
+
+func TestFromEnvOpenAILocalWithoutKey(t *testing.T) {
+	t.Setenv("LLM_PROVIDER", OpenAI)
+	t.Setenv("OPENAI_API_KEY", "")
+	for _, u := range []string{"http://localhost:8080/v1", "http://127.0.0.1:8000/v1", "http://[::1]:8000/v1"} {
+		t.Setenv("OPENAI_BASE_URL", u)
+		if _, err := FromEnv(); err != nil {
+			t.Errorf("%s: %v", u, err)
+		}
+	}
+}
+
+func TestFromEnvUnknownProvider(t *testing.T) {
+	t.Setenv("LLM_PROVIDER", "anthropic")
+	if _, err := FromEnv(); err == nil {
+		t.Error("unknown provider accepted")
+	}
+}
+
+// TestFromEnvOllama checks that the Ollama settings reach the wire: base URL, model, no key and JSON mode
+func TestFromEnvOllama(t *testing.T) {
+	var got struct {
+		path, auth string
+		body       map[string]any
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.path, got.auth = r.URL.Path, r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&got.body)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"action\":\"reply\"}"}}]}`))
+	}))
+	defer srv.Close()
+
+	t.Setenv("LLM_PROVIDER", Ollama)
+	t.Setenv("OLLAMA_BASE_URL", srv.URL+"/v1")
+	t.Setenv("OLLAMA_MODEL", "")
+	t.Setenv("OLLAMA_JSON_MODE", "false")
+
+	c, err := FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := c.GenerateText(context.Background(), "", "s", "u")
+	if err != nil || out != `{"action":"reply"}` {
+		t.Fatalf("GenerateText = %q, %v", out, err)
+	}
+	if got.path != "/v1/chat/completions" || got.auth != "" {
+		t.Errorf("path = %q, auth = %q", got.path, got.auth)
+	}
+	if got.body["model"] != "llama3.1" {
+		t.Errorf("model = %v, want the default llama3.1", got.body["model"])
+	}
+	if _, ok := got.body["response_format"]; ok {
+		t.Error("response_format sent with OLLAMA_JSON_MODE=false")
+	}
+}
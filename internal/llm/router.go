@@ -0,0 +1,67 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+)
+
+// RouterPrompt builds the system and user prompts of the triage step, shared by every provider
+func RouterPrompt(traceID, message string, history []string) (string, string) {
+	system := `Você é o roteador do Jota. 
+Sua saída deve ser EXCLUSIVAMENTE um JSON no formato:
+{"agent": "nome_do_agente", "confidence": 0.0 a 1.0, "reason": "motivo curto"}
+
+Use confidence baixa quando a mensagem for ambígua (ex: apenas uma saudação).
+
+Agentes disponíveis:
+` + agentCatalog()
+
+	var sb strings.Builder
+	sb.WriteString("trace_id: " + traceID + "\n\n")
+	sb.WriteString("Recent history:\n")
+	for i := 0; i < len(history) && i < 6; i++ {
+		sb.WriteString("- " + history[i] + "\n")
+	}
+	sb.WriteString("\nCurrent message:\n" + message + "\n")
+
+	return system, sb.String()
+}
+
+// ParseRouterDecision decodes the router output, falling back to the default agent when none is named
+func ParseRouterDecision(raw string) (RouterDecision, error) {
+	var dec RouterDecision
+	if err := json.Unmarshal([]byte(ExtractJSON(raw)), &dec); err != nil {
+		return RouterDecision{}, fmt.Errorf("router JSON parse failed: %w", err)
+	}
+
+	if dec.Agent == "" {
+		dec.Agent = core.DefaultAgent
+	}
+	return dec, nil
+}
+
+// ExtractJSON strips markdown fences and surrounding chatter some models add around a JSON object
+func ExtractJSON(raw string) string {
+	s := strings.TrimSpace(raw)
+	s = strings.TrimPrefix(s, "```json")
+	s = strings.TrimPrefix(s, "```")
+	s = strings.TrimSuffix(s, "```")
+	s = strings.TrimSpace(s)
+
+	if start, end := strings.Index(s, "{"), strings.LastIndex(s, "}"); start >= 0 && end > start {
+		s = s[start : end+1]
+	}
+	return s
+}
+
+// agentCatalog lists the registered agents for the router prompt
+func agentCatalog() string {
+	var sb strings.Builder
+	for _, spec := range core.Agents() {
+		sb.WriteString("- " + spec.Name + " (" + spec.Description + ")\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
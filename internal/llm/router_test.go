@@ -0,0 +1,33 @@
+package llm
+
+import "testing"
+
+func TestExtractJSON(t *testing.T) {
+	want := `{"agent": "golpe_med", "confidence": 0.9}`
+	cases := []struct {
+		name string
+		raw  string
+	}{
+		{"bare", want},
+		{"leading brace and trailing prose", want + "\nEspero ter ajudado."},
+		{"fenced", "```json\n" + want + "\n```"},
+		{"fenced without language", "```\n" + want + "\n```"},
+		{"leading prose", "Claro! Segue a decisão: " + want},
+		{"prose around a fence", "Segue:\n```json\n" + want + "\n```\nQualquer dúvida, avise."},
+	}
+	for _, c := range cases {
+		if got := ExtractJSON(c.raw); got != want {
+			t.Errorf("%s: got %q", c.name, got)
+		}
+	}
+}
+
+func TestParseRouterDecisionWithTrailingProse(t *testing.T) {
+	dec, err := ParseRouterDecision(`{"agent": "open_finance", "confidence": 0.8, "reason": "pix"} ok`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dec.Agent != "open_finance" || dec.Confidence != 0.8 {
+		t.Errorf("decision = %+v", dec)
+	}
+}
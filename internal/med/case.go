@@ -0,0 +1,67 @@
+package med
+
+import (
+	"fmt"
+	"time"
+)
+
+// Status is the lifecycle state of a MED case
+type Status string
+
+const (
+	StatusAberto    Status = "aberto"
+	StatusEmAnalise Status = "em_analise"
+	StatusDevolvido Status = "devolvido"
+	StatusNegado    Status = "negado"
+)
+
+// transitions lists the states each status may move to; devolvido and negado are final
+var transitions = map[Status][]Status{
+	StatusAberto:    {StatusEmAnalise, StatusNegado},
+	StatusEmAnalise: {StatusDevolvido, StatusNegado},
+}
+
+// Case is a MED (Mecanismo Especial de Devolução) request opened for a Pix scam
+type Case struct {
+	ID             string    `json:"id"`
+	ConversationID string    `json:"conversation_id"`
+	Amount         float64   `json:"amount"`
+	PixKey         string    `json:"pix_key"`
+	Date           string    `json:"date"`
+	BONumber       string    `json:"bo_number"`
+	Status         Status    `json:"status"`
+	Events         []Event   `json:"events"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Event records a status change in the case history
+type Event struct {
+	Status Status    `json:"status"`
+	Note   string    `json:"note,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// Open reports whether the case is still being processed
+func (c Case) Open() bool {
+	return c.Status == StatusAberto || c.Status == StatusEmAnalise
+}
+
+// ParseStatus validates a status coming from the API
+func ParseStatus(s string) (Status, error) {
+	switch st := Status(s); st {
+	case StatusAberto, StatusEmAnalise, StatusDevolvido, StatusNegado:
+		return st, nil
+	}
+	return "", fmt.Errorf("unknown MED status %q", s)
+}
+
+// canTransition reports whether a case may move from one status to another
+func canTransition(from, to Status) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
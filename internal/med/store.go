@@ -0,0 +1,162 @@
+package med
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when a case ID does not exist
+	ErrNotFound = errors.New("med case not found")
+	// ErrInvalidTransition is returned when a status change breaks the case lifecycle
+	ErrInvalidTransition = errors.New("invalid MED status transition")
+)
+
+// Store keeps MED cases in memory, optionally persisting them to a JSON file
+type Store struct {
+	mu    sync.Mutex
+	cases map[string]Case
+	path  string
+}
+
+// NewStore creates a case store; an empty path keeps cases in memory only
+func NewStore(path string) (*Store, error) {
+	s := &Store{cases: make(map[string]Case), path: path}
+	if path == "" {
+		return s, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create med store dir: %w", err)
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read med store file: %w", err)
+	}
+	if err := json.Unmarshal(b, &s.cases); err != nil {
+		return nil, fmt.Errorf("decode med store file: %w", err)
+	}
+	return s, nil
+}
+
+// Create opens a new case in the "aberto" status
+func (s *Store) Create(c Case) Case {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	c.ID = newCaseID()
+	c.Status = StatusAberto
+	c.Events = []Event{{Status: StatusAberto, At: now}}
+	c.CreatedAt = now
+	c.UpdatedAt = now
+
+	s.cases[c.ID] = c
+	s.save()
+	return c
+}
+
+// Get returns the case with the given ID
+func (s *Store) Get(id string) (Case, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.cases[id]
+	if !ok {
+		return Case{}, ErrNotFound
+	}
+	return c, nil
+}
+
+// List returns cases filtered by conversation and/or status (empty filters match all), newest first
+func (s *Store) List(convID string, status Status) []Case {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]Case, 0)
+	for _, c := range s.cases {
+		if convID != "" && c.ConversationID != convID {
+			continue
+		}
+		if status != "" && c.Status != status {
+			continue
+		}
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+// FindOpen returns an open case of the conversation for the same Pix key, if any
+func (s *Store) FindOpen(convID, pixKey string) (Case, bool) {
+	for _, c := range s.List(convID, "") {
+		if c.Open() && strings.EqualFold(c.PixKey, pixKey) {
+			return c, true
+		}
+	}
+	return Case{}, false
+}
+
+// UpdateStatus moves a case through its lifecycle, recording the change in its history
+func (s *Store) UpdateStatus(id string, status Status, note string) (Case, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.cases[id]
+	if !ok {
+		return Case{}, ErrNotFound
+	}
+	if !canTransition(c.Status, status) {
+		return Case{}, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, c.Status, status)
+	}
+
+	now := time.Now()
+	c.Status = status
+	c.UpdatedAt = now
+	c.Events = append(c.Events, Event{Status: status, Note: note, At: now})
+
+	s.cases[id] = c
+	s.save()
+	return c, nil
+}
+
+// save persists all cases atomically; callers must hold s.mu
+func (s *Store) save() {
+	if s.path == "" {
+		return
+	}
+
+	b, err := json.Marshal(s.cases)
+	if err != nil {
+		log.Printf("event=med_store_save_failed path=%s error=%v", s.path, err)
+		return
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		log.Printf("event=med_store_save_failed path=%s error=%v", s.path, err)
+		return
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		log.Printf("event=med_store_save_failed path=%s error=%v", s.path, err)
+	}
+}
+
+// newCaseID generates a short protocol number such as MED-1A2B3C4D
+func newCaseID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return "MED-" + strings.ToUpper(hex.EncodeToString(b))
+}
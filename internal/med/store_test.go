@@ -0,0 +1,83 @@
+package med
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestCaseLifecycle(t *testing.T) {
+	s, _ := NewStore("")
+	c := s.Create(Case{ConversationID: "c1", Amount: 250, PixKey: "golpista@gmail.com"})
+	if c.Status != StatusAberto || len(c.Events) != 1 {
+		t.Fatalf("new case = %+v", c)
+	}
+
+	if _, err := s.UpdateStatus(c.ID, StatusDevolvido, ""); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("aberto -> devolvido: err = %v", err)
+	}
+	if _, err := s.UpdateStatus(c.ID, StatusEmAnalise, "banco notificado"); err != nil {
+		t.Fatal(err)
+	}
+	c, err := s.UpdateStatus(c.ID, StatusDevolvido, "valor estornado")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Open() || len(c.Events) != 3 || c.Events[2].Note != "valor estornado" {
+		t.Errorf("closed case = %+v", c)
+	}
+	if _, err := s.UpdateStatus(c.ID, StatusNegado, ""); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("final status changed: err = %v", err)
+	}
+	if _, err := s.UpdateStatus("MED-NOPE", StatusNegado, ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown case: err = %v", err)
+	}
+}
+
+func TestFindOpenAndList(t *testing.T) {
+	s, _ := NewStore("")
+	a := s.Create(Case{ConversationID: "c1", PixKey: "Golpista@Gmail.com"})
+	s.Create(Case{ConversationID: "c2", PixKey: "golpista@gmail.com"})
+
+	if got, ok := s.FindOpen("c1", "golpista@gmail.com"); !ok || got.ID != a.ID {
+		t.Errorf("FindOpen = %+v, %t", got, ok)
+	}
+	s.UpdateStatus(a.ID, StatusNegado, "")
+	if _, ok := s.FindOpen("c1", "golpista@gmail.com"); ok {
+		t.Error("closed case reported as open")
+	}
+	if n := len(s.List("", StatusAberto)); n != 1 {
+		t.Errorf("%d open cases, want 1", n)
+	}
+	if n := len(s.List("c1", "")); n != 1 {
+		t.Errorf("%d cases in c1, want 1", n)
+	}
+}
+
+func TestStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "med", "cases.json")
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := s.Create(Case{ConversationID: "c1", Amount: 99.9})
+	s.UpdateStatus(c.ID, StatusEmAnalise, "")
+
+	reloaded, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := reloaded.Get(c.ID)
+	if err != nil || got.Status != StatusEmAnalise || got.Amount != 99.9 || len(got.Events) != 2 {
+		t.Errorf("reloaded case = %+v, %v", got, err)
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	if st, err := ParseStatus("em_analise"); err != nil || st != StatusEmAnalise {
+		t.Errorf("ParseStatus = %q, %v", st, err)
+	}
+	if _, err := ParseStatus("pendente"); err == nil {
+		t.Error("unknown status accepted")
+	}
+}
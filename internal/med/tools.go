@@ -0,0 +1,115 @@
+package med
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/tools"
+)
+
+// AbrirTool opens a MED case for the conversation (replaces the abrir_med stub)
+type AbrirTool struct {
+	store *Store
+}
+
+// NewAbrirTool creates the abrir_med tool backed by the case store
+func NewAbrirTool(store *Store) *AbrirTool {
+	return &AbrirTool{store: store}
+}
+
+func (t *AbrirTool) Name() string { return "abrir_med" }
+
+func (t *AbrirTool) Description() string {
+	return "Abre o processo MED (Mecanismo Especial de Devolução) para um Pix fraudulento e devolve o número de protocolo."
+}
+
+func (t *AbrirTool) Schema() tools.Schema {
+	return tools.Schema{Params: []tools.Param{
+		{Name: "valor", Type: tools.TypeNumber, Required: true, Description: "valor do Pix em reais"},
+		{Name: "chave_pix", Type: tools.TypeString, Required: true, Description: "chave Pix do recebedor (golpista)"},
+		{Name: "data", Type: tools.TypeString, Required: true, Description: "data do Pix no formato AAAA-MM-DD"},
+		{Name: "boletim_ocorrencia", Type: tools.TypeString, Required: true, Description: "número do Boletim de Ocorrência"},
+	}}
+}
+
+func (t *AbrirTool) Execute(ctx context.Context, call tools.Call) (map[string]any, error) {
+	pixKey, _ := call.Args["chave_pix"].(string)
+
+	// The same scam reported twice in a conversation keeps a single case
+	if c, ok := t.store.FindOpen(call.ConversationID, pixKey); ok {
+		return caseData(c, true), nil
+	}
+
+	amount, _ := call.Args["valor"].(float64)
+	if amount <= 0 {
+		return nil, fmt.Errorf("valor deve ser maior que zero")
+	}
+	date, _ := call.Args["data"].(string)
+	bo, _ := call.Args["boletim_ocorrencia"].(string)
+
+	c := t.store.Create(Case{
+		ConversationID: call.ConversationID,
+		Amount:         amount,
+		PixKey:         pixKey,
+		Date:           date,
+		BONumber:       bo,
+	})
+	return caseData(c, false), nil
+}
+
+// ConsultarTool returns the MED cases of the conversation so the agent can report their status
+type ConsultarTool struct {
+	store *Store
+}
+
+// NewConsultarTool creates the consultar_med tool backed by the case store
+func NewConsultarTool(store *Store) *ConsultarTool {
+	return &ConsultarTool{store: store}
+}
+
+func (t *ConsultarTool) Name() string { return "consultar_med" }
+
+// ReadOnly marks consultar_med as a pure query: its answers carry no security protocol banner
+func (t *ConsultarTool) ReadOnly() bool { return true }
+
+func (t *ConsultarTool) Description() string {
+	return "Consulta o status dos processos MED abertos nesta conversa."
+}
+
+func (t *ConsultarTool) Schema() tools.Schema {
+	return tools.Schema{Params: []tools.Param{
+		{Name: "protocolo", Type: tools.TypeString, Description: "número do protocolo MED, se o cliente informar"},
+	}}
+}
+
+func (t *ConsultarTool) Execute(ctx context.Context, call tools.Call) (map[string]any, error) {
+	// A protocol number only resolves within the same conversation
+	if id, _ := call.Args["protocolo"].(string); id != "" {
+		c, err := t.store.Get(id)
+		if err != nil || c.ConversationID != call.ConversationID {
+			return nil, fmt.Errorf("protocolo %s não encontrado", id)
+		}
+		return map[string]any{"casos": []map[string]any{caseData(c, false)}}, nil
+	}
+
+	cases := t.store.List(call.ConversationID, "")
+	out := make([]map[string]any, 0, len(cases))
+	for _, c := range cases {
+		out = append(out, caseData(c, false))
+	}
+	return map[string]any{"casos": out}, nil
+}
+
+// caseData is the view of a case exposed to the agent
+func caseData(c Case, existing bool) map[string]any {
+	return map[string]any{
+		"protocolo":     c.ID,
+		"status":        string(c.Status),
+		"valor":         c.Amount,
+		"chave_pix":     c.PixKey,
+		"data":          c.Date,
+		"aberto_em":     c.CreatedAt.Format("2006-01-02"),
+		"atualizado_em": c.UpdatedAt.Format("2006-01-02"),
+		"ja_existente":  existing,
+	}
+}
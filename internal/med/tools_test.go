@@ -0,0 +1,56 @@
+package med
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/tools"
+)
+
+func TestAbrirToolDeduplicatesOpenCase(t *testing.T) {
+	s, _ := NewStore("")
+	abrir := NewAbrirTool(s)
+	call := tools.Call{ConversationID: "c1", Args: map[string]any{
+		"valor": 250.0, "chave_pix": "golpista@gmail.com", "data": "2026-10-17", "boletim_ocorrencia": "2026/12345",
+	}}
+
+	first, err := abrir.Execute(context.Background(), call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first["status"] != "aberto" || first["ja_existente"] != false {
+		t.Errorf("first = %v", first)
+	}
+	second, err := abrir.Execute(context.Background(), call)
+	if err != nil || second["protocolo"] != first["protocolo"] || second["ja_existente"] != true {
+		t.Errorf("second report of the same scam = %v, %v", second, err)
+	}
+
+	call.Args = map[string]any{"valor": 0.0, "chave_pix": "outra@chave.com"}
+	if _, err := abrir.Execute(context.Background(), call); err == nil {
+		t.Error("zero amount accepted")
+	}
+}
+
+func TestConsultarToolScopesToConversation(t *testing.T) {
+	s, _ := NewStore("")
+	mine := s.Create(Case{ConversationID: "c1"})
+	other := s.Create(Case{ConversationID: "c2"})
+	consultar := NewConsultarTool(s)
+
+	out, err := consultar.Execute(context.Background(), tools.Call{ConversationID: "c1", Args: map[string]any{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cases := out["casos"].([]map[string]any); len(cases) != 1 || cases[0]["protocolo"] != mine.ID {
+		t.Errorf("casos = %v", cases)
+	}
+
+	call := tools.Call{ConversationID: "c1", Args: map[string]any{"protocolo": other.ID}}
+	if _, err := consultar.Execute(context.Background(), call); err == nil {
+		t.Error("case of another conversation disclosed by protocol number")
+	}
+	if !consultar.ReadOnly() {
+		t.Error("consultar_med must be read-only")
+	}
+}
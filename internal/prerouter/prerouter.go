@@ -0,0 +1,127 @@
+package prerouter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rule is a weighted pattern that votes for an agent (and optionally for immediate escalation)
+type Rule struct {
+	Name     string  `json:"name"`
+	Pattern  string  `json:"pattern"`
+	Weight   float64 `json:"weight"`
+	Agent    string  `json:"agent"`
+	Escalate bool    `json:"escalate"`
+
+	re *regexp.Regexp
+}
+
+// Decision is the outcome of a pre-routing evaluation
+type Decision struct {
+	Agent    string
+	Escalate bool
+	Score    float64
+	Rules    []string
+}
+
+// PreRouter evaluates deterministic rules before any LLM is involved
+type PreRouter struct {
+	rules     []Rule
+	threshold float64
+}
+
+// New compiles the rules; a decision is taken when the accumulated weight reaches threshold
+func New(rules []Rule, threshold float64) (*PreRouter, error) {
+	compiled := make([]Rule, 0, len(rules))
+	for _, r := range rules {
+		if r.Agent == "" {
+			return nil, fmt.Errorf("prerouter rule %q: agent is required", r.Name)
+		}
+		// Patterns are matched against lowercase, accent-free text
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("prerouter rule %q: %w", r.Name, err)
+		}
+		r.re = re
+		compiled = append(compiled, r)
+	}
+	return &PreRouter{rules: compiled, threshold: threshold}, nil
+}
+
+// LoadFile reads a JSON array of rules from path
+func LoadFile(path string, threshold float64) (*PreRouter, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []Rule
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, fmt.Errorf("decode prerouter rules: %w", err)
+	}
+	return New(rules, threshold)
+}
+
+// Evaluate scores the message and returns a decision when any target reaches the threshold.
+// Escalation wins over plain routing.
+func (p *PreRouter) Evaluate(message string) (Decision, bool) {
+	text := normalize(message)
+
+	agentScore := make(map[string]float64)
+	escalateScore := make(map[string]float64)
+	matched := make(map[string][]string)
+
+	for _, r := range p.rules {
+		if !r.re.MatchString(text) {
+			continue
+		}
+		agentScore[r.Agent] += r.Weight
+		if r.Escalate {
+			escalateScore[r.Agent] += r.Weight
+		}
+		matched[r.Agent] = append(matched[r.Agent], r.Name)
+	}
+
+	if agent, score := best(escalateScore); score >= p.threshold {
+		return Decision{Agent: agent, Escalate: true, Score: score, Rules: matched[agent]}, true
+	}
+	if agent, score := best(agentScore); score >= p.threshold {
+		return Decision{Agent: agent, Score: score, Rules: matched[agent]}, true
+	}
+	return Decision{}, false
+}
+
+// best returns the highest scoring agent, breaking ties by name for determinism
+func best(scores map[string]float64) (string, float64) {
+	agents := make([]string, 0, len(scores))
+	for a := range scores {
+		agents = append(agents, a)
+	}
+	sort.Strings(agents)
+
+	top, topScore := "", 0.0
+	for _, a := range agents {
+		if scores[a] > topScore {
+			top, topScore = a, scores[a]
+		}
+	}
+	return top, topScore
+}
+
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a",
+	"é", "e", "ê", "e",
+	"í", "i",
+	"ó", "o", "ô", "o", "õ", "o",
+	"ú", "u", "ü", "u",
+	"ç", "c",
+)
+
+// normalize lowercases and strips Portuguese accents so patterns stay simple
+func normalize(s string) string {
+	return accents.Replace(strings.ToLower(s))
+}
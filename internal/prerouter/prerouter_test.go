@@ -0,0 +1,59 @@
+package prerouter
+
+import "testing"
+
+func TestDefaultRulesStolenPhone(t *testing.T) {
+	p, err := New(DefaultRules, 1.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		msg      string
+		escalate bool
+	}{
+		{"roubaram meu celular ontem", true},
+		{"levaram o telefone no ônibus", true},
+		{"meu celular foi roubado", true},
+		{"o aparelho foi furtado no metrô", true},
+		{"perdi o celular", true},
+		{"perdi meu celular no show", true},
+		{"Perdi o meu telefone", true},
+		{"meu celular foi perdido", true},
+		{"troquei de celular e perdi a senha do app", false},
+		{"perdi a senha do celular", false},
+		{"perdi o prazo do boleto, estava sem celular", false},
+		{"quero cadastrar um celular novo", false},
+	}
+	for _, c := range cases {
+		d, ok := p.Evaluate(c.msg)
+		if got := ok && d.Escalate; got != c.escalate {
+			t.Errorf("Evaluate(%q) escalate = %t, want %t (rules %v)", c.msg, got, c.escalate, d.Rules)
+		}
+	}
+}
+
+func TestEvaluateRoutesByWeight(t *testing.T) {
+	p, err := New(DefaultRules, 1.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Weak signals alone stay with the LLM router
+	if d, ok := p.Evaluate("fiz um pix ontem"); ok {
+		t.Errorf("single weak rule decided: %+v", d)
+	}
+	d, ok := p.Evaluate("caí num golpe do pix")
+	if !ok || d.Agent != "golpe_med" || d.Escalate {
+		t.Errorf("golpe + pix = %+v, %t; want golpe_med without escalation", d, ok)
+	}
+}
+
+func TestNewRejectsInvalidRules(t *testing.T) {
+	if _, err := New([]Rule{{Name: "x", Pattern: "a"}}, 1); err == nil {
+		t.Error("rule without agent accepted")
+	}
+	if _, err := New([]Rule{{Name: "x", Pattern: "(", Agent: "a"}}, 1); err == nil {
+		t.Error("invalid pattern accepted")
+	}
+}
@@ -0,0 +1,23 @@
+package prerouter
+
+// DefaultRules covers security incidents that must never depend on the LLM.
+// Patterns run on lowercase text without accents ("invasão" -> "invasao").
+var DefaultRules = []Rule{
+	// Account takeover: always a human case
+	{Name: "hacker", Pattern: `\b(hacker|hackea(ram|do|da)|hackear)\b`, Weight: 1.0, Agent: "golpe_med", Escalate: true},
+	{Name: "invasao", Pattern: `\b(invasao|invadi(ram|da|do|u))\b`, Weight: 1.0, Agent: "golpe_med", Escalate: true},
+	{Name: "acesso_indevido", Pattern: `\b(acessaram|mexeram|entraram) (na )?minha conta\b`, Weight: 1.0, Agent: "golpe_med", Escalate: true},
+
+	// Stolen or lost phone: the account must be blocked by a human right away
+	{Name: "celular_roubado", Pattern: `\b(celular|telefone|aparelho|whatsapp)\b.*\b(roubad[oa]|furtad[oa]|levaram)\b`, Weight: 1.0, Agent: "golpe_med", Escalate: true},
+	{Name: "roubaram_celular", Pattern: `\b(roubaram|furtaram|levaram)\b.*\b(celular|telefone|aparelho)\b`, Weight: 1.0, Agent: "golpe_med", Escalate: true},
+	// "perdi" alone is too common ("perdi a senha"), so it only counts right next to the device
+	{Name: "celular_perdido", Pattern: `\b(perdi|perderam) (o |meu |o meu )?(celular|telefone|aparelho)\b|\b(celular|telefone|aparelho) (foi )?perdid[oa]\b`, Weight: 1.0, Agent: "golpe_med", Escalate: true},
+
+	// Pix scams: routed to the MED specialist, which collects the case data
+	{Name: "golpe", Pattern: `\bgolpes?\b`, Weight: 0.7, Agent: "golpe_med"},
+	{Name: "fraude", Pattern: `\b(fraude|fraudulent[oa]|estelionat\w*|me enganaram|fui enganad[oa])\b`, Weight: 0.7, Agent: "golpe_med"},
+	{Name: "vitima", Pattern: `\b(cai|caiu) (num|em um|no)\b`, Weight: 0.3, Agent: "golpe_med"},
+	{Name: "pix", Pattern: `\bpix\b`, Weight: 0.3, Agent: "golpe_med"},
+	{Name: "med", Pattern: `\b(med|mecanismo especial de devolucao)\b`, Weight: 1.0, Agent: "golpe_med"},
+}
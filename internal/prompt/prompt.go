@@ -0,0 +1,152 @@
+package prompt
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+)
+
+// DefaultTokenBudget bounds the dynamic context of a prompt (history, RAG, notes, slots)
+const DefaultTokenBudget = 4000
+
+var budget = DefaultTokenBudget
+
+// historyOverhead reserves room for the history header and the omitted-turns notice
+var historyOverhead = EstimateTokens("\nHistórico da conversa:\n(0000 mensagens anteriores omitidas)\n\n")
+
+// SetTokenBudget changes the token budget used by Assemble (0 disables trimming)
+func SetTokenBudget(n int) {
+	budget = n
+}
+
+// Context is the dynamic part of an agent prompt, already fitted to the token budget
+type Context struct {
+	// RAG is the knowledge base excerpt to place in the system prompt
+	RAG string
+	// User is the complete user prompt: context blocks, history and current message
+	User string
+	// DroppedTurns counts the oldest history messages left out to respect the budget
+	DroppedTurns int
+}
+
+// EstimateTokens approximates the token count of s (about 4 characters per token)
+func EstimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + 3) / 4
+}
+
+// Assemble builds the prompt context every brain sends to the model.
+// Extra blocks (e.g. document validation) are placed after the handoff note and
+// the rolling summary right before the recent history.
+// When over budget, the oldest turns are dropped first and the RAG excerpt is cut last.
+func Assemble(in core.BrainInput, extra ...string) Context {
+	var head strings.Builder
+	for _, b := range append([]string{in.ToolResult.PromptBlock(), in.Slots, in.Handoff.PromptBlock()}, extra...) {
+		if b = strings.TrimRight(b, "\n"); b != "" {
+			head.WriteString(b + "\n\n")
+		}
+	}
+	if in.Summary != "" {
+		head.WriteString("RESUMO DA CONVERSA ATÉ AQUI (mensagens mais antigas):\n" + in.Summary + "\n\n")
+	}
+	tail := "Mensagem atual do cliente:\n\"" + in.UserMessage + "\"\n\nGere o ActionPlan em JSON:"
+
+	turns := historyTurns(in.History, in.UserMessage)
+	rag := in.RAGContext
+
+	// Head and tail always go in. The RAG excerpt comes next and is only cut when it does not
+	// fit on its own; history then fills what is left, newest turns first.
+	keep := len(turns)
+	if budget > 0 {
+		free := budget - EstimateTokens(head.String()) - EstimateTokens(tail) - historyOverhead
+		if EstimateTokens(rag) > free {
+			rag = cut(rag, free)
+		}
+		free -= EstimateTokens(rag)
+
+		keep = 0
+		for i := len(turns) - 1; i >= 0; i-- {
+			cost := EstimateTokens(turns[i]) + 1
+			if cost > free {
+				break
+			}
+			free -= cost
+			keep++
+		}
+		if free < 0 {
+			log.Printf("trace=%s conv=%s event=prompt_over_budget budget=%d over=%d",
+				in.TraceID, in.ConversationID, budget, -free)
+		}
+	}
+	dropped := len(turns) - keep
+
+	var sb strings.Builder
+	sb.WriteString(head.String())
+	sb.WriteString("Histórico da conversa:\n")
+	switch {
+	case dropped > 0:
+		sb.WriteString("(" + strconv.Itoa(dropped) + " mensagens anteriores omitidas)\n")
+	case len(turns) == 0:
+		sb.WriteString("(início da conversa)\n")
+	}
+	for _, t := range turns[dropped:] {
+		sb.WriteString(t + "\n")
+	}
+	sb.WriteString("\n" + tail)
+
+	if dropped > 0 || rag != in.RAGContext {
+		log.Printf("trace=%s conv=%s event=prompt_trimmed dropped_turns=%d rag_cut=%t budget=%d",
+			in.TraceID, in.ConversationID, dropped, rag != in.RAGContext, budget)
+	}
+
+	return Context{RAG: rag, User: sb.String(), DroppedTurns: dropped}
+}
+
+// historyTurns renders the history as "Role: text" lines, without the message being answered
+func historyTurns(history []core.ChatMessage, userMessage string) []string {
+	if n := len(history); n > 0 && history[n-1].Role == "user" && history[n-1].Text == userMessage {
+		history = history[:n-1]
+	}
+
+	out := make([]string, 0, len(history))
+	for _, msg := range history {
+		out = append(out, roleLabel(msg.Role)+": "+msg.Text)
+	}
+	return out
+}
+
+// roleLabel names each participant the way the agents see the conversation
+func roleLabel(role string) string {
+	switch role {
+	case "assistant":
+		return "Você (Especialista)"
+	case "human":
+		return "Atendente humano"
+	}
+	return "Cliente"
+}
+
+// cut shortens s to about maxTokens, breaking at the last line that fits
+func cut(s string, maxTokens int) string {
+	if maxTokens <= 0 {
+		return ""
+	}
+	r := []rune(s)
+	if len(r) <= maxTokens*4 {
+		return s
+	}
+	// Leave room for the "[...]" marker so the result stays within maxTokens
+	limit := maxTokens*4 - utf8.RuneCountInString(cutMarker)
+	if limit <= 0 {
+		return ""
+	}
+	out := string(r[:limit])
+	if i := strings.LastIndex(out, "\n"); i > 0 {
+		out = out[:i]
+	}
+	return out + cutMarker
+}
+
+const cutMarker = "\n[...]"
@@ -0,0 +1,148 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+)
+
+func withBudget(t *testing.T, n int) {
+	t.Helper()
+	prev := budget
+	SetTokenBudget(n)
+	t.Cleanup(func() { SetTokenBudget(prev) })
+}
+
+// history builds n alternating turns of roughly size characters each
+func history(n, size int) []core.ChatMessage {
+	out := make([]core.ChatMessage, 0, n)
+	for i := 0; i < n; i++ {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		text := fmt.Sprintf("turno-%02d ", i) + strings.Repeat("x", size)
+		out = append(out, core.ChatMessage{Role: role, Text: text})
+	}
+	return out
+}
+
+func used(c Context) int {
+	return EstimateTokens(c.User) + EstimateTokens(c.RAG)
+}
+
+func TestAssembleKeepsNewestTurnsWithinBudget(t *testing.T) {
+	withBudget(t, 400)
+	in := core.BrainInput{
+		History:     history(40, 120),
+		UserMessage: "qual o status?",
+		RAGContext:  strings.Repeat("base de conhecimento\n", 10),
+	}
+
+	c := Assemble(in)
+	if got := used(c); got > 400 {
+		t.Fatalf("prompt uses %d tokens, budget is 400", got)
+	}
+	if c.DroppedTurns == 0 || c.DroppedTurns == 40 {
+		t.Fatalf("dropped %d turns, want some but not all", c.DroppedTurns)
+	}
+	if c.RAG != in.RAGContext {
+		t.Error("RAG was cut although dropping history was enough")
+	}
+
+	// Exactly the newest turns survive
+	kept := 40 - c.DroppedTurns
+	for i := 0; i < 40; i++ {
+		present := strings.Contains(c.User, fmt.Sprintf("turno-%02d ", i))
+		if want := i >= 40-kept; present != want {
+			t.Errorf("turn %d present=%t, want %t", i, present, want)
+		}
+	}
+	if !strings.Contains(c.User, fmt.Sprintf("(%d mensagens anteriores omitidas)", c.DroppedTurns)) {
+		t.Error("missing omitted-turns notice")
+	}
+}
+
+func TestAssembleKeepsRAGWhenOnlyHistoryOverflows(t *testing.T) {
+	rag := strings.Repeat("r", 400) // 100 tokens
+	in := core.BrainInput{
+		History:     history(2, 800), // each turn alone is over 200 tokens
+		UserMessage: "oi",
+		RAGContext:  rag,
+	}
+	withBudget(t, EstimateTokens("Mensagem atual do cliente:\n\"oi\"\n\nGere o ActionPlan em JSON:")+historyOverhead+150)
+
+	c := Assemble(in)
+	if c.RAG != rag {
+		t.Errorf("RAG cut to %d runes although it fits", len(c.RAG))
+	}
+	if c.DroppedTurns != 2 {
+		t.Errorf("dropped %d turns, want 2", c.DroppedTurns)
+	}
+	if got := used(c); got > budget {
+		t.Errorf("prompt uses %d tokens, budget is %d", got, budget)
+	}
+}
+
+func TestAssembleCutsRAGLast(t *testing.T) {
+	withBudget(t, 200)
+	in := core.BrainInput{
+		History:     history(6, 40),
+		UserMessage: "oi",
+		RAGContext:  strings.Repeat("linha da base de conhecimento\n", 60), // ~450 tokens
+	}
+
+	c := Assemble(in)
+	if c.RAG == in.RAGContext || !strings.HasSuffix(c.RAG, "[...]") {
+		t.Fatal("RAG larger than the budget was not cut")
+	}
+	if c.DroppedTurns != 6 {
+		t.Errorf("dropped %d turns, want all 6 before cutting RAG", c.DroppedTurns)
+	}
+	if got := used(c); got > 200 {
+		t.Errorf("prompt uses %d tokens, budget is 200", got)
+	}
+}
+
+func TestAssembleFixedBlocksOverBudget(t *testing.T) {
+	withBudget(t, 10)
+	in := core.BrainInput{
+		History:     history(4, 40),
+		UserMessage: strings.Repeat("mensagem longa ", 20),
+		RAGContext:  "rag",
+	}
+
+	// The current message is never dropped; everything optional goes
+	c := Assemble(in)
+	if c.RAG != "" || c.DroppedTurns != 4 || !strings.Contains(c.User, in.UserMessage) {
+		t.Errorf("unexpected context: rag=%q dropped=%d", c.RAG, c.DroppedTurns)
+	}
+}
+
+func TestAssembleNoBudget(t *testing.T) {
+	withBudget(t, 0)
+	in := core.BrainInput{History: history(30, 400), UserMessage: "oi", RAGContext: strings.Repeat("r", 10000)}
+
+	c := Assemble(in)
+	if c.DroppedTurns != 0 || c.RAG != in.RAGContext {
+		t.Errorf("budget 0 must disable trimming: dropped=%d", c.DroppedTurns)
+	}
+}
+
+func TestAssembleSkipsCurrentMessageInHistory(t *testing.T) {
+	withBudget(t, DefaultTokenBudget)
+	in := core.BrainInput{
+		History:     []core.ChatMessage{{Role: "assistant", Text: "Olá!"}, {Role: "user", Text: "meu pix"}},
+		UserMessage: "meu pix",
+	}
+
+	c := Assemble(in)
+	if strings.Count(c.User, "meu pix") != 1 {
+		t.Errorf("current message duplicated:\n%s", c.User)
+	}
+	if !strings.Contains(c.User, "Você (Especialista): Olá!") {
+		t.Errorf("history missing:\n%s", c.User)
+	}
+}
@@ -0,0 +1,317 @@
+package prompt
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+	"github.com/bonettibruno/Jota_ProdOps/internal/tools"
+)
+
+//go:embed templates/*.tmpl
+var embedded embed.FS
+
+// versionRe reads the {{/* version: v1 */}} header of a template file
+var versionRe = regexp.MustCompile(`^\{\{-?\s*/\*\s*version:\s*(\S+)\s*\*/`)
+
+// Data is exposed to every system prompt template
+type Data struct {
+	Name          string
+	Persona       string
+	Actions       string
+	HandoffList   string
+	TransferRules string
+	Tools         string
+	RAG           string
+}
+
+// DataFor fills the template data from the agent's registry entry
+func DataFor(spec core.AgentSpec, rag string) Data {
+	return Data{
+		Name:          spec.Name,
+		Actions:       spec.ActionList(),
+		HandoffList:   core.HandoffList(spec.Name),
+		TransferRules: core.TransferRules(spec.Name),
+		Tools:         tools.PromptCatalog(spec.Tools),
+		RAG:           rag,
+	}
+}
+
+// Template is a compiled system prompt with its version identifier
+type Template struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Source  string `json:"source"`
+
+	tmpl *template.Template
+}
+
+// ID identifies the exact prompt that produced a reply, e.g. golpe_med@v1
+func (t *Template) ID() string {
+	return t.Name + "@" + t.Version
+}
+
+// Render executes the template with the given data
+func (t *Template) Render(data any) (string, error) {
+	var sb strings.Builder
+	if err := t.tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("prompt %s: render: %w", t.ID(), err)
+	}
+	return sb.String(), nil
+}
+
+// Compile parses a template, taking the version from its header or, without one, from its content hash
+func Compile(name, source, text string) (*Template, error) {
+	version := ""
+	if m := versionRe.FindStringSubmatch(text); m != nil {
+		version = m[1]
+	} else {
+		sum := sha256.Sum256([]byte(text))
+		version = "sha-" + hex.EncodeToString(sum[:4])
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("prompt %s: invalid template: %w", name, err)
+	}
+	return &Template{Name: name, Version: version, Source: source, tmpl: tmpl}, nil
+}
+
+var (
+	mu        sync.RWMutex
+	templates = map[string]*Template{}
+	dir       string
+	// registered holds the default templates that agent packages ship themselves
+	registered = map[string]string{}
+)
+
+func init() {
+	set, err := loadSet("")
+	if err != nil {
+		panic(err) // embedded templates are part of the build
+	}
+	templates = set
+}
+
+// RegisterDefault lets an agent package ship its own default template, so a new Go agent
+// needs no file under internal/prompt/templates. PROMPTS_DIR files still override it.
+// It panics on an invalid template, like the embedded ones, since it runs from init.
+func RegisterDefault(name, text string) {
+	t, err := Compile(name, "agent", text)
+	if err != nil {
+		panic(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	registered[name] = text
+	if cur, ok := templates[name]; !ok || cur.Source == "embedded" {
+		templates[name] = t
+	}
+}
+
+// Lookup returns the current template of an agent
+func Lookup(name string) (*Template, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	t, ok := templates[name]
+	if !ok {
+		return nil, fmt.Errorf("prompt template %q not found", name)
+	}
+	return t, nil
+}
+
+type templateKey struct{}
+
+// WithTemplate makes System use another template for calls made with ctx (e.g. an experiment variant)
+func WithTemplate(ctx context.Context, name string) context.Context {
+	if name == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, templateKey{}, name)
+}
+
+// TemplateFrom returns the template override carried by ctx, if any
+func TemplateFrom(ctx context.Context) string {
+	name, _ := ctx.Value(templateKey{}).(string)
+	return name
+}
+
+// System renders the system prompt of an agent (or the template set in ctx) and returns it with the template ID
+func System(ctx context.Context, spec core.AgentSpec, rag string) (string, string, error) {
+	name := spec.Name
+	if override := TemplateFrom(ctx); override != "" {
+		name = override
+	}
+	t, err := Lookup(name)
+	if err != nil {
+		return "", "", err
+	}
+	text, err := t.Render(DataFor(spec, rag))
+	if err != nil {
+		return "", "", err
+	}
+	return text, t.ID(), nil
+}
+
+// Templates lists the active templates sorted by name
+func Templates() []Template {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	out := make([]Template, 0, len(templates))
+	for _, t := range templates {
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// SetDir makes *.tmpl files in d override the embedded defaults and loads them
+func SetDir(d string) error {
+	set, err := loadSet(d)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	dir = d
+	templates = set
+	mu.Unlock()
+	return nil
+}
+
+// Reload re-reads the override directory; on error the previous templates stay active
+func Reload() error {
+	mu.RLock()
+	d := dir
+	mu.RUnlock()
+	if d == "" {
+		return nil
+	}
+	return SetDir(d)
+}
+
+// Watch reloads the override directory whenever one of its files changes
+func Watch(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		last := dirStamp()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				stamp := dirStamp()
+				if stamp == last {
+					continue
+				}
+				last = stamp
+				if err := Reload(); err != nil {
+					log.Printf("event=prompts_reload_failed error=%v", err)
+					continue
+				}
+				log.Printf("event=prompts_reloaded versions=%s", versionList())
+			}
+		}
+	}()
+}
+
+// loadSet compiles the embedded templates and the overrides found in d
+func loadSet(d string) (map[string]*Template, error) {
+	set := make(map[string]*Template)
+
+	files, _ := fs.Glob(embedded, "templates/*.tmpl")
+	for _, f := range files {
+		b, err := embedded.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimSuffix(filepath.Base(f), ".tmpl")
+		t, err := Compile(name, "embedded", string(b))
+		if err != nil {
+			return nil, err
+		}
+		set[name] = t
+	}
+
+	mu.RLock()
+	for name, text := range registered {
+		t, err := Compile(name, "agent", text)
+		if err != nil {
+			mu.RUnlock()
+			return nil, err
+		}
+		set[name] = t
+	}
+	mu.RUnlock()
+
+	if d == "" {
+		return set, nil
+	}
+	if _, err := os.Stat(d); err != nil {
+		return nil, fmt.Errorf("prompts dir: %w", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(d, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", f, err)
+		}
+		name := strings.TrimSuffix(filepath.Base(f), ".tmpl")
+		t, err := Compile(name, f, string(b))
+		if err != nil {
+			return nil, err
+		}
+		set[name] = t
+	}
+	return set, nil
+}
+
+// dirStamp summarizes names, sizes and mtimes of the override files to detect changes
+func dirStamp() string {
+	mu.RLock()
+	d := dir
+	mu.RUnlock()
+	if d == "" {
+		return ""
+	}
+
+	files, _ := filepath.Glob(filepath.Join(d, "*.tmpl"))
+	var sb strings.Builder
+	for _, f := range files {
+		if fi, err := os.Stat(f); err == nil {
+			fmt.Fprintf(&sb, "%s:%d:%d;", f, fi.Size(), fi.ModTime().UnixNano())
+		}
+	}
+	return sb.String()
+}
+
+// versionList renders the active template IDs for logs
+func versionList() string {
+	var ids []string
+	for _, t := range Templates() {
+		ids = append(ids, t.ID())
+	}
+	return strings.Join(ids, ",")
+}
@@ -0,0 +1,53 @@
+package prompt
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+)
+
+func TestRegisterDefault(t *testing.T) {
+	RegisterDefault("tmpl_teste", "{{/* version: v3 */ -}}\nVocê é o agente {{.Name}}.")
+
+	text, id, err := System(context.Background(), core.AgentSpec{Name: "tmpl_teste"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "Você é o agente tmpl_teste." || id != "tmpl_teste@v3" {
+		t.Errorf("System = %q, %q", text, id)
+	}
+
+	// A PROMPTS_DIR file overrides the registered default, which comes back when the dir goes
+	d := t.TempDir()
+	if err := os.WriteFile(filepath.Join(d, "tmpl_teste.tmpl"), []byte("{{/* version: v4 */ -}}\noverride"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetDir(d); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetDir("") })
+	if tmpl, _ := Lookup("tmpl_teste"); tmpl.ID() != "tmpl_teste@v4" {
+		t.Errorf("override not applied: %s", tmpl.ID())
+	}
+	if _, err := Lookup("golpe_med"); err != nil {
+		t.Errorf("embedded templates lost: %v", err)
+	}
+
+	SetDir("")
+	if tmpl, err := Lookup("tmpl_teste"); err != nil || tmpl.Source != "agent" {
+		t.Errorf("registered default lost after reload: %v", err)
+	}
+}
+
+func TestRegisterDefaultRejectsInvalidTemplate(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(error).Error(), "tmpl_quebrado") {
+			t.Errorf("recover = %v, want a panic naming the template", r)
+		}
+	}()
+	RegisterDefault("tmpl_quebrado", "{{.Name")
+}
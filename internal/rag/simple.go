@@ -10,6 +10,7 @@ import (
 // Chunk represents a segment of the knowledge base for retrieval
 type Chunk struct {
 	Title   string
+	Section string
 	Content string
 	Score   int
 }
@@ -40,13 +41,21 @@ func NewRetriever(path string) (*Retriever, error) {
 
 // Search performs a token-based ranking over document chunks
 func (r *Retriever) Search(query string, topK int) []Chunk {
-	qTokens := tokenize(query)
+	return r.SearchSections(query, topK, nil)
+}
+
+// SearchSections ranks only the chunks whose section or title matches one of sections (nil means all)
+func (r *Retriever) SearchSections(query string, topK int, sections []string) []Chunk {
+	qTokens := Tokenize(query)
 	if len(qTokens) == 0 {
 		return nil
 	}
 
 	results := make([]Chunk, 0, len(r.chunks))
 	for _, c := range r.chunks {
+		if !inSections(c, sections) {
+			continue
+		}
 		score := scoreChunk(qTokens, c.Title+" "+c.Content)
 		if score > 0 {
 			cc := c
@@ -72,6 +81,7 @@ func splitMarkdownByHeadings(md string) []Chunk {
 	var chunks []Chunk
 
 	currentTitle := "General Document"
+	currentSection := ""
 	var buf []string
 
 	headingRe := regexp.MustCompile(`^\s{0,3}(#{1,6})\s+(.+?)\s*$`)
@@ -81,6 +91,7 @@ func splitMarkdownByHeadings(md string) []Chunk {
 		if content != "" {
 			chunks = append(chunks, Chunk{
 				Title:   currentTitle,
+				Section: currentSection,
 				Content: content,
 			})
 		}
@@ -91,6 +102,10 @@ func splitMarkdownByHeadings(md string) []Chunk {
 		if m := headingRe.FindStringSubmatch(line); m != nil {
 			flush()
 			currentTitle = strings.TrimSpace(m[2])
+			// H1/H2 headings open a new top-level section (e.g. "3. Open Finance")
+			if len(m[1]) <= 2 {
+				currentSection = currentTitle
+			}
 			continue
 		}
 		buf = append(buf, line)
@@ -100,8 +115,8 @@ func splitMarkdownByHeadings(md string) []Chunk {
 	return chunks
 }
 
-// tokenize cleans and filters the query string for better matching
-func tokenize(s string) []string {
+// Tokenize cleans and filters the query string for better matching
+func Tokenize(s string) []string {
 	s = strings.ToLower(s)
 	// Remove basic punctuation
 	re := regexp.MustCompile(`[^\p{L}\p{N}\s]+`)
@@ -124,6 +139,20 @@ func tokenize(s string) []string {
 	return out
 }
 
+// inSections reports whether the chunk belongs to any of the requested sections
+func inSections(c Chunk, sections []string) bool {
+	if len(sections) == 0 {
+		return true
+	}
+	for _, sec := range sections {
+		sec = strings.ToLower(sec)
+		if strings.Contains(strings.ToLower(c.Section), sec) || strings.Contains(strings.ToLower(c.Title), sec) {
+			return true
+		}
+	}
+	return false
+}
+
 // scoreChunk counts keyword occurrences in a text block
 func scoreChunk(qTokens []string, text string) int {
 	t := strings.ToLower(text)
@@ -146,7 +175,12 @@ func (r *Retriever) AsText() string {
 
 // SearchAsText formats the search results for LLM injection
 func (r *Retriever) SearchAsText(query string, topK int) string {
-	chunks := r.Search(query, topK)
+	return r.SearchSectionsAsText(query, topK, nil)
+}
+
+// SearchSectionsAsText formats section-restricted search results for LLM injection
+func (r *Retriever) SearchSectionsAsText(query string, topK int, sections []string) string {
+	chunks := r.SearchSections(query, topK, sections)
 	if len(chunks) == 0 {
 		return "No relevant information found in the knowledge base."
 	}
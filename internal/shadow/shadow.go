@@ -0,0 +1,256 @@
+package shadow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+)
+
+// Record pairs the production plan with the plan the shadow candidate produced for the same input
+type Record struct {
+	TraceID          string          `json:"trace_id"`
+	ConversationID   string          `json:"conversation_id"`
+	Agent            string          `json:"agent"`
+	Candidate        string          `json:"candidate"`
+	Production       core.ActionPlan `json:"production"`
+	Shadow           core.ActionPlan `json:"shadow"`
+	Error            string          `json:"error,omitempty"`
+	ActionMatch      bool            `json:"action_match"`
+	ChangeAgentMatch bool            `json:"change_agent_match"`
+	LatencyMs        int64           `json:"latency_ms"`
+	CreatedAt        time.Time       `json:"created_at"`
+}
+
+// AgentReport aggregates the agreement between an agent and its shadow candidate
+type AgentReport struct {
+	Agent                string  `json:"agent"`
+	Candidate            string  `json:"candidate"`
+	Samples              int     `json:"samples"`
+	Errors               int     `json:"errors"`
+	ActionAgreement      float64 `json:"action_agreement"`
+	ChangeAgentAgreement float64 `json:"change_agent_agreement"`
+}
+
+type tally struct {
+	candidate     string
+	samples       int
+	errors        int
+	actionOK      int
+	changeAgentOK int
+}
+
+// Runner executes shadow candidates asynchronously and keeps their records
+type Runner struct {
+	client  any
+	timeout time.Duration
+	slots   chan struct{}
+
+	mu      sync.Mutex
+	records []Record
+	max     int
+	tallies map[string]*tally
+	dropped int
+	path    string
+}
+
+// Config tunes a Runner
+type Config struct {
+	// Concurrency caps shadow runs in flight; extra requests are dropped, never queued
+	Concurrency int
+	// Keep is how many records stay in memory for diffing
+	Keep int
+	// Path optionally appends every record as a JSON line
+	Path string
+	// Timeout bounds each shadow run
+	Timeout time.Duration
+}
+
+// NewRunner creates a runner that calls candidates with the given LLM client
+func NewRunner(client any, cfg Config) (*Runner, error) {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 4
+	}
+	if cfg.Keep <= 0 {
+		cfg.Keep = 1000
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.Path != "" {
+		if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o755); err != nil {
+			return nil, fmt.Errorf("create shadow dir: %w", err)
+		}
+	}
+
+	return &Runner{
+		client:  client,
+		timeout: cfg.Timeout,
+		slots:   make(chan struct{}, cfg.Concurrency),
+		max:     cfg.Keep,
+		tallies: make(map[string]*tally),
+		path:    cfg.Path,
+	}, nil
+}
+
+// Submit runs the agent's shadow (if any) in background with the production inputs.
+// The production plan must be policy-enforced but taken before any orchestrator guard (e.g. the
+// form's slot guard), since the shadow plan only goes through the same policy.
+func (r *Runner) Submit(spec core.AgentSpec, in core.BrainInput, production core.ActionPlan) {
+	cand, ok := core.LookupShadow(spec.Name)
+	if !ok {
+		return
+	}
+
+	select {
+	case r.slots <- struct{}{}:
+	default:
+		r.mu.Lock()
+		r.dropped++
+		r.mu.Unlock()
+		log.Printf("trace=%s conv=%s event=shadow_dropped agent=%s", in.TraceID, in.ConversationID, spec.Name)
+		return
+	}
+
+	go func() {
+		defer func() { <-r.slots }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+		defer cancel()
+
+		start := time.Now()
+		rec := Record{
+			TraceID:        in.TraceID,
+			ConversationID: in.ConversationID,
+			Agent:          spec.Name,
+			Candidate:      cand.Name,
+			Production:     production,
+			CreatedAt:      start,
+		}
+
+		plan, err := cand.Brain.Run(ctx, r.client, in)
+		if err == nil {
+			var valid bool
+			plan, _, valid = core.EnforcePolicy(spec, plan)
+			if !valid {
+				err = fmt.Errorf("plan rejected by policy")
+			}
+		}
+		rec.LatencyMs = time.Since(start).Milliseconds()
+		if err != nil {
+			rec.Error = err.Error()
+		} else {
+			rec.Shadow = plan
+			rec.ActionMatch = plan.Action == production.Action
+			rec.ChangeAgentMatch = target(plan) == target(production)
+		}
+
+		r.add(rec)
+		log.Printf("trace=%s conv=%s event=shadow_compared agent=%s candidate=%s action_match=%t change_agent_match=%t error=%q",
+			in.TraceID, in.ConversationID, spec.Name, cand.Name, rec.ActionMatch, rec.ChangeAgentMatch, rec.Error)
+	}()
+}
+
+// target is the handoff destination of a plan, empty when it does not transfer
+func target(p core.ActionPlan) string {
+	if p.Action != "change_agent" {
+		return ""
+	}
+	return p.ChangeAgent
+}
+
+// add stores a record, updates the tallies and appends it to the log file
+func (r *Runner) add(rec Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.records = append(r.records, rec)
+	if len(r.records) > r.max {
+		r.records = r.records[len(r.records)-r.max:]
+	}
+
+	t, ok := r.tallies[rec.Agent]
+	if !ok {
+		t = &tally{}
+		r.tallies[rec.Agent] = t
+	}
+	t.candidate = rec.Candidate
+	t.samples++
+	switch {
+	case rec.Error != "":
+		t.errors++
+	default:
+		if rec.ActionMatch {
+			t.actionOK++
+		}
+		if rec.ChangeAgentMatch {
+			t.changeAgentOK++
+		}
+	}
+
+	if r.path == "" {
+		return
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		log.Printf("event=shadow_log_failed path=%s error=%v", r.path, err)
+		return
+	}
+	defer f.Close()
+	_, _ = f.Write(append(b, '\n'))
+}
+
+// Report returns the agreement per agent; rates are computed over runs that did not fail
+func (r *Runner) Report() []AgentReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]AgentReport, 0, len(r.tallies))
+	for agent, t := range r.tallies {
+		rep := AgentReport{Agent: agent, Candidate: t.candidate, Samples: t.samples, Errors: t.errors}
+		if ok := t.samples - t.errors; ok > 0 {
+			rep.ActionAgreement = float64(t.actionOK) / float64(ok)
+			rep.ChangeAgentAgreement = float64(t.changeAgentOK) / float64(ok)
+		}
+		out = append(out, rep)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Agent < out[j].Agent })
+	return out
+}
+
+// Dropped counts shadow runs skipped because the concurrency limit was reached
+func (r *Runner) Dropped() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.dropped
+}
+
+// Records returns the most recent records (newest first), optionally filtered by agent and disagreement
+func (r *Runner) Records(agent string, onlyDiffs bool, limit int) []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]Record, 0)
+	for i := len(r.records) - 1; i >= 0 && (limit <= 0 || len(out) < limit); i-- {
+		rec := r.records[i]
+		if agent != "" && rec.Agent != agent {
+			continue
+		}
+		if onlyDiffs && rec.Error == "" && rec.ActionMatch && rec.ChangeAgentMatch {
+			continue
+		}
+		out = append(out, rec)
+	}
+	return out
+}
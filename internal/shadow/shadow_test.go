@@ -0,0 +1,163 @@
+package shadow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+)
+
+// scriptedBrain returns its plans in order; when gate is set each run waits for it
+type scriptedBrain struct {
+	plans   chan core.ActionPlan
+	err     error
+	started chan struct{}
+	gate    chan struct{}
+}
+
+func (b *scriptedBrain) Run(ctx context.Context, client any, in core.BrainInput) (core.ActionPlan, error) {
+	if b.started != nil {
+		b.started <- struct{}{}
+	}
+	if b.gate != nil {
+		<-b.gate
+	}
+	if b.err != nil {
+		return core.ActionPlan{}, b.err
+	}
+	return <-b.plans, nil
+}
+
+var agentSeq atomic.Int64
+
+// register attaches brain as the shadow of a fresh agent; the registry is global and has no removal
+func register(t *testing.T, candidate string, brain core.AgentBrain) string {
+	agent := fmt.Sprintf("%s_%d", t.Name(), agentSeq.Add(1))
+	core.RegisterShadow(agent, core.Shadow{Name: candidate, Brain: brain})
+	return agent
+}
+
+func spec(name string) core.AgentSpec {
+	return core.AgentSpec{Name: name, Actions: []string{"reply", "ask", "escalate"}}
+}
+
+// waitSamples blocks until the agent's report has n samples
+func waitSamples(t *testing.T, r *Runner, agent string, n int) AgentReport {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, rep := range r.Report() {
+			if rep.Agent == agent && rep.Samples == n {
+				return rep
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("%s never reached %d samples: %+v", agent, n, r.Report())
+	return AgentReport{}
+}
+
+func TestSubmitCountsAgreement(t *testing.T) {
+	brain := &scriptedBrain{plans: make(chan core.ActionPlan, 3)}
+	brain.plans <- core.ActionPlan{Action: "reply", Message: "a"}
+	brain.plans <- core.ActionPlan{Action: "escalate", Message: "b"}
+	// Not allowed for the agent: the policy downgrades it to reply, as it would in production
+	brain.plans <- core.ActionPlan{Action: "end", Message: "c"}
+	agent := register(t, "cand_v2", brain)
+
+	r, err := NewRunner(nil, Config{Concurrency: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prod := core.ActionPlan{Action: "reply", Message: "prod"}
+	var rep AgentReport
+	for i := 0; i < 3; i++ {
+		r.Submit(spec(agent), core.BrainInput{TraceID: "t", ConversationID: "c"}, prod)
+		rep = waitSamples(t, r, agent, i+1)
+	}
+
+	if rep.Candidate != "cand_v2" || rep.Errors != 0 {
+		t.Errorf("report = %+v", rep)
+	}
+	if rep.ActionAgreement < 0.66 || rep.ActionAgreement > 0.67 {
+		t.Errorf("action agreement = %.2f, want 2/3", rep.ActionAgreement)
+	}
+	if rep.ChangeAgentAgreement != 1 {
+		t.Errorf("change_agent agreement = %.2f, want 1 (no plan transfers)", rep.ChangeAgentAgreement)
+	}
+
+	all := r.Records(agent, false, 0)
+	if len(all) != 3 || all[0].Shadow.Message != "c" {
+		t.Fatalf("records not newest first: %+v", all)
+	}
+	diffs := r.Records(agent, true, 0)
+	if len(diffs) != 1 || diffs[0].Shadow.Action != "escalate" {
+		t.Errorf("diffs = %+v", diffs)
+	}
+	if got := r.Records("other", false, 0); len(got) != 0 {
+		t.Errorf("records leaked to another agent: %+v", got)
+	}
+	if got := r.Records("", false, 1); len(got) != 1 {
+		t.Errorf("limit ignored: %d records", len(got))
+	}
+}
+
+func TestSubmitErrorsDoNotCountAsAgreement(t *testing.T) {
+	agent := register(t, "cand", &scriptedBrain{err: errors.New("boom")})
+
+	r, err := NewRunner(nil, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Submit(spec(agent), core.BrainInput{}, core.ActionPlan{Action: "reply"})
+
+	rep := waitSamples(t, r, agent, 1)
+	if rep.Errors != 1 || rep.ActionAgreement != 0 {
+		t.Errorf("report = %+v", rep)
+	}
+	if diffs := r.Records(agent, true, 0); len(diffs) != 1 || diffs[0].Error == "" {
+		t.Errorf("failed run missing from diffs: %+v", diffs)
+	}
+}
+
+func TestSubmitDropsBeyondConcurrency(t *testing.T) {
+	brain := &scriptedBrain{
+		plans:   make(chan core.ActionPlan, 1),
+		started: make(chan struct{}, 1),
+		gate:    make(chan struct{}),
+	}
+	brain.plans <- core.ActionPlan{Action: "reply"}
+	agent := register(t, "cand", brain)
+
+	r, err := NewRunner(nil, Config{Concurrency: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := core.BrainInput{TraceID: "t", ConversationID: "c"}
+	r.Submit(spec(agent), in, core.ActionPlan{Action: "reply"})
+	<-brain.started
+
+	r.Submit(spec(agent), in, core.ActionPlan{Action: "reply"})
+	r.Submit(spec(agent), in, core.ActionPlan{Action: "reply"})
+	if got := r.Dropped(); got != 2 {
+		t.Errorf("dropped = %d, want 2", got)
+	}
+
+	close(brain.gate)
+	waitSamples(t, r, agent, 1)
+}
+
+func TestSubmitWithoutShadowIsNoop(t *testing.T) {
+	r, err := NewRunner(nil, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Submit(spec("no_shadow"), core.BrainInput{}, core.ActionPlan{Action: "reply"})
+	if len(r.Report()) != 0 || r.Dropped() != 0 {
+		t.Errorf("runner recorded an agent without shadow: %+v", r.Report())
+	}
+}
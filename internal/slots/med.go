@@ -0,0 +1,219 @@
+package slots
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/brdoc"
+)
+
+// medDeadline is the window the MED rules give the victim to contest a Pix
+const medDeadline = 80 * 24 * time.Hour
+
+// MED collects the data required by the abrir_med tool
+var MED = &Form{
+	Name: "med",
+	Tool: "abrir_med",
+	Slots: []Slot{
+		{
+			Name:     "valor",
+			Label:    "Valor do Pix",
+			Question: "Qual foi o valor do Pix enviado ao golpista?",
+			Numeric:  true,
+			Extract:  extractAmount,
+			Validate: validateAmount,
+		},
+		{
+			Name:     "chave_pix",
+			Label:    "Chave Pix do recebedor",
+			Question: "Qual foi a chave Pix (CPF, CNPJ, telefone, e-mail ou chave aleatória) para onde o dinheiro foi enviado?",
+			Extract:  extractPixKey,
+			Validate: validatePixKey,
+		},
+		{
+			Name:     "data",
+			Label:    "Data do Pix",
+			Question: "Em que data você fez o Pix?",
+			Extract:  extractDate,
+			Validate: validateDate,
+		},
+		{
+			Name:     "boletim_ocorrencia",
+			Label:    "Número do B.O.",
+			Question: "Você já registrou o Boletim de Ocorrência? Se sim, qual é o número?",
+			Extract:  extractBO,
+			Validate: validateBO,
+		},
+	},
+}
+
+func init() {
+	Register(MED)
+}
+
+var (
+	// The grouped branch needs at least one ".ddd" group and a word boundary, so "1500" and
+	// "2500.50" fall through to the plain branch instead of stopping after three digits
+	amountRe      = regexp.MustCompile(`(?i)r\$\s*(\d{1,3}(?:\.\d{3})+(?:,\d{1,2})?\b|\d+(?:[.,]\d{1,2})?)`)
+	amountWordsRe = regexp.MustCompile(`(?i)\b(\d{1,3}(?:\.\d{3})+(?:,\d{1,2})?\b|\d+(?:,\d{1,2})?)\s*(?:reais|real|conto)\b`)
+	thousandsRe   = regexp.MustCompile(`^\d{1,3}(?:\.\d{3})+(?:,\d{1,2})?$`)
+)
+
+func extractAmount(msg string, _ time.Time) (string, bool) {
+	if m := amountRe.FindStringSubmatch(msg); m != nil {
+		return m[1], true
+	}
+	if m := amountWordsRe.FindStringSubmatch(msg); m != nil {
+		return m[1], true
+	}
+	return "", false
+}
+
+// validateAmount accepts Brazilian ("1.234,56") and plain ("1234.56") notations
+func validateAmount(v string, _ time.Time) (string, error) {
+	v = strings.TrimSpace(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(v)), "R$"))
+	// Dots grouping thousands ("1.500", "1.500,00") are dropped; a comma is the decimal separator
+	if thousandsRe.MatchString(v) {
+		v = strings.ReplaceAll(v, ".", "")
+	}
+	v = strings.ReplaceAll(v, ",", ".")
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return "", fmt.Errorf("valor %q não é um número válido", v)
+	}
+	if n <= 0 {
+		return "", fmt.Errorf("o valor precisa ser maior que zero")
+	}
+	return strconv.FormatFloat(n, 'f', 2, 64), nil
+}
+
+var (
+	dateBRRe  = regexp.MustCompile(`\b(\d{1,2})/(\d{1,2})(?:/(\d{2}|\d{4}))?\b`)
+	dateISORe = regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})\b`)
+	dateRelRe = regexp.MustCompile(`(?i)\b(hoje|ontem|anteontem)\b`)
+)
+
+func extractDate(msg string, now time.Time) (string, bool) {
+	if m := dateISORe.FindString(msg); m != "" {
+		return m, true
+	}
+	if m := dateBRRe.FindString(msg); m != "" {
+		return m, true
+	}
+	if m := dateRelRe.FindString(msg); m != "" {
+		return strings.ToLower(m), true
+	}
+	return "", false
+}
+
+// validateDate normalizes to AAAA-MM-DD and enforces the MED window (not in the future, at most 80 days)
+func validateDate(v string, now time.Time) (string, error) {
+	v = strings.TrimSpace(strings.ToLower(v))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var d time.Time
+	switch {
+	case v == "hoje":
+		d = today
+	case v == "ontem":
+		d = today.AddDate(0, 0, -1)
+	case v == "anteontem":
+		d = today.AddDate(0, 0, -2)
+	case dateISORe.MatchString(v):
+		t, err := time.ParseInLocation("2006-01-02", v, now.Location())
+		if err != nil {
+			return "", fmt.Errorf("data %q inválida", v)
+		}
+		d = t
+	default:
+		m := dateBRRe.FindStringSubmatch(v)
+		if m == nil {
+			return "", fmt.Errorf("data %q não reconhecida", v)
+		}
+		day, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		year := now.Year()
+		if m[3] != "" {
+			year, _ = strconv.Atoi(m[3])
+			if year < 100 {
+				year += 2000
+			}
+		}
+		d = time.Date(year, time.Month(month), day, 0, 0, 0, 0, now.Location())
+		// time.Date normalizes overflow (31/02 -> 03/03): reject instead
+		if d.Day() != day || int(d.Month()) != month {
+			return "", fmt.Errorf("data %q inválida", v)
+		}
+	}
+
+	if d.After(today) {
+		return "", fmt.Errorf("a data %s está no futuro", d.Format("02/01/2006"))
+	}
+	if today.Sub(d) > medDeadline {
+		return "", fmt.Errorf("a data %s está fora do prazo de 80 dias do MED", d.Format("02/01/2006"))
+	}
+	return d.Format("2006-01-02"), nil
+}
+
+var (
+	emailRe = regexp.MustCompile(`(?i)\b[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}\b`)
+	evpRe   = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	cnpjRe  = regexp.MustCompile(`\b\d{2}\.?\d{3}\.?\d{3}/?\d{4}-?\d{2}\b`)
+	cpfRe   = regexp.MustCompile(`\b\d{3}\.?\d{3}\.?\d{3}-?\d{2}\b`)
+	phoneRe = regexp.MustCompile(`(?:\+?55\s?)?\(?\b\d{2}\)?\s?9?\d{4}-?\d{4}\b`)
+	pixCtx  = regexp.MustCompile(`(?i)\b(chave|pix)\b`)
+)
+
+func extractPixKey(msg string, _ time.Time) (string, bool) {
+	for _, re := range []*regexp.Regexp{emailRe, evpRe, cnpjRe, cpfRe} {
+		if m := re.FindString(msg); m != "" {
+			return m, true
+		}
+	}
+	// Phone numbers are too ambiguous without mentioning a Pix key
+	if pixCtx.MatchString(msg) {
+		if m := phoneRe.FindString(msg); m != "" {
+			return m, true
+		}
+	}
+	return "", false
+}
+
+// validatePixKey checks formats and CPF/CNPJ check digits, normalizing the key
+func validatePixKey(v string, _ time.Time) (string, error) {
+	key, err := brdoc.ParsePixKey(v)
+	if err != nil {
+		return "", err
+	}
+	return key.Value, nil
+}
+
+var (
+	boRe       = regexp.MustCompile(`(?i)\b(?:b\.?\s?o\.?|boletim(?: de ocorr[eê]ncia)?)\b[^0-9a-z]{0,12}(?:n[º°o.]?\s*)?([a-z]{0,4}\d[\w\-/.]{3,})`)
+	boAffirmRe = regexp.MustCompile(`(?i)\b(registrei|fiz|tenho|abri)\b.*\b(b\.?\s?o\.?|boletim)`)
+)
+
+func extractBO(msg string, _ time.Time) (string, bool) {
+	if m := boRe.FindStringSubmatch(msg); m != nil {
+		return m[1], true
+	}
+	// The customer confirmed the B.O. but did not give its number
+	if boAffirmRe.MatchString(msg) {
+		return "", true
+	}
+	return "", false
+}
+
+func validateBO(v string, _ time.Time) (string, error) {
+	v = strings.TrimSpace(strings.TrimRight(v, "."))
+	if v == "" {
+		return "", fmt.Errorf("o cliente confirmou o B.O. mas ainda não informou o número")
+	}
+	if len(v) < 4 {
+		return "", fmt.Errorf("número do B.O. %q parece incompleto", v)
+	}
+	return strings.ToUpper(v), nil
+}
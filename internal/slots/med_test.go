@@ -0,0 +1,62 @@
+package slots
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateAmount(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "1.500", want: "1500.00"},
+		{in: "1.500,00", want: "1500.00"},
+		{in: "1.234.567,89", want: "1234567.89"},
+		{in: "R$ 50", want: "50.00"},
+		{in: "50,5", want: "50.50"},
+		{in: "1234.56", want: "1234.56"},
+		{in: "0", wantErr: true},
+		{in: "abc", wantErr: true},
+	}
+	for _, c := range cases {
+		got, err := validateAmount(c.in, time.Now())
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("validateAmount(%q) = %q, want error", c.in, got)
+			}
+			continue
+		}
+		if err != nil || got != c.want {
+			t.Errorf("validateAmount(%q) = %q, %v; want %q", c.in, got, err, c.want)
+		}
+	}
+}
+
+func TestExtractAmount(t *testing.T) {
+	cases := []struct {
+		msg  string
+		want string
+	}{
+		{"paguei R$ 1500 ontem", "1500.00"},
+		{"foram R$1500,00 pelo pix", "1500.00"},
+		{"transferi R$ 2500.50 para ele", "2500.50"},
+		{"paguei R$ 1.500,00 ontem", "1500.00"},
+		{"paguei R$ 1.500 ontem", "1500.00"},
+		{"R$ 50", "50.00"},
+		{"foram 1500 reais", "1500.00"},
+		{"foram 1.500 reais", "1500.00"},
+		{"uns 250,90 reais", "250.90"},
+	}
+	for _, c := range cases {
+		raw, ok := extractAmount(c.msg, time.Now())
+		if !ok {
+			t.Errorf("extractAmount(%q) found nothing", c.msg)
+			continue
+		}
+		if got, err := validateAmount(raw, time.Now()); err != nil || got != c.want {
+			t.Errorf("%q: extracted %q -> %q, %v; want %s", c.msg, raw, got, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package slots
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Slot declares a piece of structured data an agent must collect from the customer
+type Slot struct {
+	Name     string
+	Label    string
+	Question string
+	// Numeric slots are passed to the tool as numbers instead of strings
+	Numeric bool
+	// Extract finds a candidate value in a customer message
+	Extract func(message string, now time.Time) (string, bool)
+	// Validate normalizes a candidate value or explains why it is invalid
+	Validate func(value string, now time.Time) (string, error)
+}
+
+// Form is a set of slots guarding a tool: the tool may only run once every slot is valid
+type Form struct {
+	Name  string
+	Tool  string
+	Slots []Slot
+}
+
+// State holds the valid values collected so far, keyed by slot name
+type State map[string]string
+
+// Problem describes a value the customer gave that failed validation
+type Problem struct {
+	Slot    string
+	Message string
+}
+
+var registry = struct {
+	mu    sync.RWMutex
+	forms map[string]*Form
+}{forms: make(map[string]*Form)}
+
+// Register makes a form available to agents through AgentSpec.Form
+func Register(f *Form) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.forms[f.Name] = f
+}
+
+// Lookup returns the registered form with the given name
+func Lookup(name string) (*Form, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	f, ok := registry.forms[name]
+	return f, ok
+}
+
+// attrPrefix namespaces the form's slots inside the conversation attributes
+func (f *Form) attrPrefix() string {
+	return "slot." + f.Name + "."
+}
+
+// Load reads the form state from conversation attributes
+func (f *Form) Load(attrs map[string]string) State {
+	st := make(State)
+	for _, s := range f.Slots {
+		if v := attrs[f.attrPrefix()+s.Name]; v != "" {
+			st[s.Name] = v
+		}
+	}
+	return st
+}
+
+// AttrKey returns the conversation attribute key that stores a slot
+func (f *Form) AttrKey(slot string) string {
+	return f.attrPrefix() + slot
+}
+
+// SubmittedKey returns the conversation attribute recording when the form's tool last ran
+func (f *Form) SubmittedKey() string {
+	return "form." + f.Name + ".enviado_em"
+}
+
+// ExtractFrom runs every slot extractor over the messages (later messages win) and
+// returns the slots updated with valid values plus the problems found
+func (f *Form) ExtractFrom(messages []string, now time.Time) (State, []Problem) {
+	updates := make(State)
+	var problems []Problem
+
+	for _, msg := range messages {
+		for _, s := range f.Slots {
+			raw, ok := s.Extract(msg, now)
+			if !ok {
+				continue
+			}
+			v, err := s.Validate(raw, now)
+			if err != nil {
+				problems = append(problems, Problem{Slot: s.Name, Message: err.Error()})
+				continue
+			}
+			updates[s.Name] = v
+		}
+	}
+	return updates, problems
+}
+
+// Merge validates values proposed by the LLM (ActionPlan.Slots) for known slots
+func (f *Form) Merge(proposed map[string]string, now time.Time) (State, []Problem) {
+	updates := make(State)
+	var problems []Problem
+
+	for _, s := range f.Slots {
+		raw := strings.TrimSpace(proposed[s.Name])
+		if raw == "" || raw == "null" {
+			continue
+		}
+		v, err := s.Validate(raw, now)
+		if err != nil {
+			problems = append(problems, Problem{Slot: s.Name, Message: err.Error()})
+			continue
+		}
+		updates[s.Name] = v
+	}
+	return updates, problems
+}
+
+// Missing returns the slots still without a valid value, in form order
+func (f *Form) Missing(st State) []Slot {
+	var out []Slot
+	for _, s := range f.Slots {
+		if st[s.Name] == "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Complete reports whether every slot has a valid value
+func (f *Form) Complete(st State) bool {
+	return len(f.Missing(st)) == 0
+}
+
+// PromptBlock renders the slot state for injection in the agent's prompt
+func (f *Form) PromptBlock(st State, problems []Problem) string {
+	var sb strings.Builder
+	sb.WriteString("DADOS COLETADOS (" + f.Name + "):\n")
+	for _, s := range f.Slots {
+		v := st[s.Name]
+		if v == "" {
+			v = "PENDENTE"
+		}
+		sb.WriteString("- " + s.Label + " (" + s.Name + "): " + v + "\n")
+	}
+
+	if len(problems) > 0 {
+		sort.SliceStable(problems, func(i, j int) bool { return problems[i].Slot < problems[j].Slot })
+		sb.WriteString("Problemas nos dados informados pelo cliente:\n")
+		for _, p := range problems {
+			sb.WriteString("- " + p.Slot + ": " + p.Message + "\n")
+		}
+	}
+
+	if missing := f.Missing(st); len(missing) > 0 {
+		sb.WriteString("Não use a tool \"" + f.Tool + "\" antes de coletar os dados PENDENTES. Não pergunte de novo o que já foi coletado.\n")
+	} else {
+		sb.WriteString("Todos os dados foram coletados: você já pode usar a tool \"" + f.Tool + "\".\n")
+	}
+	return sb.String()
+}
+
+// ToolArgs converts a state into arguments for the guarded tool
+func (f *Form) ToolArgs(st State) map[string]any {
+	args := make(map[string]any, len(st))
+	for _, s := range f.Slots {
+		v, ok := st[s.Name]
+		if !ok {
+			continue
+		}
+		if s.Numeric {
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				args[s.Name] = n
+				continue
+			}
+		}
+		args[s.Name] = v
+	}
+	return args
+}
+
+// NextQuestion returns the question for the first missing slot
+func (f *Form) NextQuestion(st State) string {
+	missing := f.Missing(st)
+	if len(missing) == 0 {
+		return ""
+	}
+	return missing[0].Question
+}
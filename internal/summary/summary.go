@@ -0,0 +1,117 @@
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm"
+)
+
+// maxRunes caps the summary size so it never competes with the recent history
+const maxRunes = 1500
+
+// Summarizer condenses old conversation turns into a rolling summary
+type Summarizer struct {
+	client llm.Client
+}
+
+// New creates a summarizer; a nil client always uses the deterministic fallback
+func New(client llm.Client) *Summarizer {
+	return &Summarizer{client: client}
+}
+
+// Summarize merges the previous summary with the turns leaving the history window.
+// Any LLM failure falls back to a deterministic digest so no turn is silently lost.
+func (s *Summarizer) Summarize(ctx context.Context, traceID, previous string, turns []core.ChatMessage) string {
+	if s.client != nil {
+		sum, err := s.llmSummary(ctx, traceID, previous, turns)
+		if err == nil {
+			return clip(sum)
+		}
+		log.Printf("trace=%s event=summary_fallback err=%v", traceID, err)
+	}
+	return Fallback(previous, turns)
+}
+
+// llmSummary asks the model for a JSON {"summary": "..."} condensing the conversation
+func (s *Summarizer) llmSummary(ctx context.Context, traceID, previous string, turns []core.ChatMessage) (string, error) {
+	system := `Você resume atendimentos do Jota para que outro agente continue a conversa sem perder contexto.
+Escreva em português, em no máximo 8 frases curtas, preservando:
+- o problema do cliente e os dados que ele já informou (valores, datas, chaves, bancos);
+- os passos e soluções já tentados e o resultado de cada um;
+- transferências, protocolos e decisões tomadas.
+Não invente informações.
+
+RESPOSTA EXCLUSIVAMENTE EM JSON:
+{"summary": "resumo aqui"}`
+
+	var sb strings.Builder
+	if previous != "" {
+		sb.WriteString("Resumo anterior:\n" + previous + "\n\n")
+	}
+	sb.WriteString("Mensagens a incorporar ao resumo:\n")
+	for _, t := range turns {
+		sb.WriteString(roleLabel(t.Role) + ": " + t.Text + "\n")
+	}
+
+	raw, err := s.client.GenerateText(ctx, traceID, system, sb.String())
+	if err != nil {
+		return "", err
+	}
+
+	var out struct {
+		Summary string `json:"summary"`
+	}
+	if err := json.Unmarshal([]byte(raw), &out); err != nil {
+		return "", fmt.Errorf("failed to decode summary JSON: %w", err)
+	}
+	if strings.TrimSpace(out.Summary) == "" {
+		return "", fmt.Errorf("empty summary")
+	}
+	return strings.TrimSpace(out.Summary), nil
+}
+
+// Fallback builds a deterministic digest: the previous summary followed by one short line per turn
+func Fallback(previous string, turns []core.ChatMessage) string {
+	lines := make([]string, 0, len(turns)+1)
+	if previous != "" {
+		lines = append(lines, previous)
+	}
+	for _, t := range turns {
+		lines = append(lines, "- "+roleLabel(t.Role)+": "+shorten(t.Text, 120))
+	}
+	return clip(strings.Join(lines, "\n"))
+}
+
+// roleLabel names each participant in the summary input
+func roleLabel(role string) string {
+	switch role {
+	case "assistant":
+		return "Assistente"
+	case "human":
+		return "Atendente humano"
+	}
+	return "Cliente"
+}
+
+// shorten cuts s to n runes
+func shorten(s string, n int) string {
+	r := []rune(strings.Join(strings.Fields(s), " "))
+	if len(r) <= n {
+		return string(r)
+	}
+	return string(r[:n-3]) + "..."
+}
+
+// clip keeps the most recent part of an oversized summary
+func clip(s string) string {
+	r := []rune(s)
+	if len(r) <= maxRunes {
+		return s
+	}
+	return "..." + string(r[len(r)-maxRunes+3:])
+}
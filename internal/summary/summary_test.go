@@ -0,0 +1,69 @@
+package summary
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+	"github.com/bonettibruno/Jota_ProdOps/internal/llm/fake"
+)
+
+var turns = []core.ChatMessage{
+	{Role: "user", Text: "fiz um pix de R$ 300 para um golpista"},
+	{Role: "assistant", Text: "Sinto muito. Qual a chave Pix?"},
+	{Role: "human", Text: "Assumi o atendimento."},
+}
+
+func TestSummarizeUsesLLM(t *testing.T) {
+	llm := fake.New().On(fake.Rule{Response: `{"summary": "  Cliente caiu em golpe de R$ 300.  "}`})
+	got := New(llm).Summarize(context.Background(), "t1", "Resumo antigo", turns)
+
+	if got != "Cliente caiu em golpe de R$ 300." {
+		t.Errorf("summary = %q", got)
+	}
+	calls := llm.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("%d LLM calls, want 1", len(calls))
+	}
+	for _, want := range []string{"Resumo anterior:\nResumo antigo", "Cliente: fiz um pix", "Assistente: Sinto muito", "Atendente humano: Assumi"} {
+		if !strings.Contains(calls[0].User, want) {
+			t.Errorf("prompt missing %q:\n%s", want, calls[0].User)
+		}
+	}
+}
+
+func TestSummarizeFallsBack(t *testing.T) {
+	cases := map[string]*Summarizer{
+		"error":      New(fake.New().On(fake.Rule{Err: errors.New("quota exceeded")})),
+		"not json":   New(fake.New().On(fake.Rule{Response: "Cliente caiu em golpe"})),
+		"empty":      New(fake.New().On(fake.Rule{Response: `{"summary": " "}`})),
+		"nil client": New(nil),
+	}
+	want := Fallback("", turns)
+	for name, s := range cases {
+		if got := s.Summarize(context.Background(), "", "", turns); got != want {
+			t.Errorf("%s: summary = %q, want the fallback", name, got)
+		}
+	}
+}
+
+func TestFallback(t *testing.T) {
+	long := strings.Repeat("palavra ", 40)
+	got := Fallback("Resumo antigo", []core.ChatMessage{{Role: "user", Text: long}})
+
+	lines := strings.Split(got, "\n")
+	if len(lines) != 2 || lines[0] != "Resumo antigo" {
+		t.Fatalf("fallback = %q", got)
+	}
+	if r := []rune(strings.TrimPrefix(lines[1], "- Cliente: ")); len(r) != 120 || !strings.HasSuffix(lines[1], "...") {
+		t.Errorf("turn not shortened to 120 runes: %q", lines[1])
+	}
+
+	// The digest keeps the newest part when it outgrows the cap
+	big := Fallback(strings.Repeat("a", 2000), turns)
+	if n := len([]rune(big)); n != maxRunes || !strings.HasPrefix(big, "...") || !strings.HasSuffix(big, "Assumi o atendimento.") {
+		t.Errorf("clipped summary has %d runes: %q...", n, big[:20])
+	}
+}
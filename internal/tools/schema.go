@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Supported argument types
+const (
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+)
+
+// Param declares a single tool argument
+type Param struct {
+	Name        string
+	Type        string
+	Required    bool
+	Description string
+}
+
+// Schema declares the arguments a tool accepts
+type Schema struct {
+	Params []Param
+}
+
+// Validate checks required arguments, types and rejects arguments the tool does not know
+func (s Schema) Validate(args map[string]any) error {
+	var problems []string
+
+	known := make(map[string]bool, len(s.Params))
+	for _, p := range s.Params {
+		known[p.Name] = true
+
+		v, ok := args[p.Name]
+		if !ok || v == nil || v == "" {
+			if p.Required {
+				problems = append(problems, fmt.Sprintf("argumento obrigatório ausente: %s", p.Name))
+			}
+			continue
+		}
+		if !hasType(v, p.Type) {
+			problems = append(problems, fmt.Sprintf("argumento %s deve ser %s", p.Name, p.Type))
+		}
+	}
+
+	for name := range args {
+		if !known[name] {
+			problems = append(problems, fmt.Sprintf("argumento desconhecido: %s", name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("argumentos inválidos: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// hasType matches values decoded by encoding/json against a schema type
+func hasType(v any, typ string) bool {
+	switch typ {
+	case TypeString:
+		_, ok := v.(string)
+		return ok
+	case TypeNumber:
+		switch v.(type) {
+		case float64, float32, int, int64:
+			return true
+		}
+		return false
+	case TypeBoolean:
+		_, ok := v.(bool)
+		return ok
+	}
+	return false
+}
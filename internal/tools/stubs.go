@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+func init() {
+	// Local stubs keep dev and tests offline; real integrations replace them via Register
+	Register(&AbrirMEDStub{})
+}
+
+// AbrirMEDStub simulates opening a MED (Mecanismo Especial de Devolução) request
+type AbrirMEDStub struct{}
+
+func (t *AbrirMEDStub) Name() string { return "abrir_med" }
+
+func (t *AbrirMEDStub) Description() string {
+	return "Abre o processo MED (Mecanismo Especial de Devolução) para um Pix fraudulento."
+}
+
+func (t *AbrirMEDStub) Schema() Schema {
+	return Schema{Params: []Param{
+		{Name: "valor", Type: TypeNumber, Required: true, Description: "valor do Pix em reais"},
+		{Name: "chave_pix", Type: TypeString, Required: true, Description: "chave Pix do recebedor (golpista)"},
+		{Name: "data", Type: TypeString, Required: true, Description: "data do Pix no formato AAAA-MM-DD"},
+		{Name: "boletim_ocorrencia", Type: TypeString, Required: true, Description: "número do Boletim de Ocorrência"},
+	}}
+}
+
+func (t *AbrirMEDStub) Execute(ctx context.Context, call Call) (map[string]any, error) {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return map[string]any{
+		"protocolo": "MED-" + hex.EncodeToString(b),
+		"status":    "aberto",
+		"simulado":  true,
+	}, nil
+}
@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bonettibruno/Jota_ProdOps/internal/core"
+)
+
+// Tool is an operation an agent can request through a "call_api" ActionPlan
+type Tool interface {
+	// Name is the identifier used in ActionPlan.Tool
+	Name() string
+	// Description tells the agent what the tool does
+	Description() string
+	// Schema declares the accepted arguments
+	Schema() Schema
+	// Execute runs the tool with arguments already validated against Schema
+	Execute(ctx context.Context, call Call) (map[string]any, error)
+}
+
+// ReadOnly is implemented by tools that only query data and never act on the customer's behalf
+type ReadOnly interface {
+	ReadOnly() bool
+}
+
+// Call carries the arguments and the conversation context of a tool execution
+type Call struct {
+	TraceID        string
+	ConversationID string
+	Args           map[string]any
+}
+
+var registry = struct {
+	mu    sync.RWMutex
+	tools map[string]Tool
+}{tools: make(map[string]Tool)}
+
+// Register makes a tool available, replacing any tool with the same name (e.g. a local stub)
+func Register(t Tool) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.tools[t.Name()] = t
+}
+
+// Lookup returns the registered tool with the given name
+func Lookup(name string) (Tool, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	t, ok := registry.tools[name]
+	return t, ok
+}
+
+// IsReadOnly reports whether the named tool only queries data (e.g. consultar_med)
+func IsReadOnly(name string) bool {
+	t, ok := Lookup(name)
+	if !ok {
+		return false
+	}
+	ro, ok := t.(ReadOnly)
+	return ok && ro.ReadOnly()
+}
+
+// Run validates the arguments and executes the named tool, always returning a result for the agent
+func Run(ctx context.Context, name string, call Call) core.ToolResult {
+	t, ok := Lookup(name)
+	if !ok {
+		return core.ToolResult{Tool: name, Error: fmt.Sprintf("tool %q não existe", name)}
+	}
+	if err := t.Schema().Validate(call.Args); err != nil {
+		return core.ToolResult{Tool: name, Error: err.Error()}
+	}
+
+	data, err := t.Execute(ctx, call)
+	if err != nil {
+		return core.ToolResult{Tool: name, Error: err.Error()}
+	}
+	return core.ToolResult{Tool: name, OK: true, Data: data}
+}
+
+// PromptCatalog describes the given tools and their arguments for an agent prompt
+func PromptCatalog(names []string) string {
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+
+	var sb strings.Builder
+	for _, name := range sorted {
+		t, ok := Lookup(name)
+		if !ok {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("- %q: %s\n", t.Name(), t.Description()))
+		for _, p := range t.Schema().Params {
+			req := "opcional"
+			if p.Required {
+				req = "obrigatório"
+			}
+			sb.WriteString(fmt.Sprintf("    - %s (%s, %s): %s\n", p.Name, p.Type, req, p.Description))
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}